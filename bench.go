@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/philw07/pich8-go/internal/bench"
+)
+
+// runBench runs the given ROM without rendering or audio and prints the measurements
+func runBench(args []string) error {
+	flags := flag.NewFlagSet("bench", flag.ContinueOnError)
+	seconds := flags.Float64("seconds", 10, "duration of the benchmark in seconds")
+	ipf := flags.Int("ipf", 12, "instructions per frame, the timers are updated once per frame")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: pich8-go bench [flags] rom.ch8")
+		flags.PrintDefaults()
+	}
+
+	// Allow flags before and after the ROM path
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() < 1 {
+		flags.Usage()
+		return errors.New("no ROM given")
+	}
+	file := flags.Arg(0)
+	if err := flags.Parse(flags.Args()[1:]); err != nil {
+		return err
+	}
+
+	rom, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	res, err := bench.Run(rom, time.Duration(*seconds*float64(time.Second)), *ipf)
+	if err != nil {
+		return err
+	}
+	fmt.Println(res)
+	return nil
+}
@@ -0,0 +1,336 @@
+// Command libpich8 builds the emulator core as a C shared library, the header is generated along with it:
+//
+//	go build -buildmode=c-shared -o libpich8.so ./cmd/libpich8
+//
+// The machines are referenced by handles, functions returning an int report errors with -1,
+// the message is copied by pich8_last_error.
+package main
+
+/*
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+
+// The machines are referenced by the handles returned by pich8_create.
+// Functions returning an int return -1 on errors, the message is copied by pich8_last_error.
+
+static void copy_message(char *buf, int size, const char *msg) {
+	snprintf(buf, size, "%s", msg);
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"unsafe"
+
+	"github.com/philw07/pich8-go/pkg/chip8"
+)
+
+//go:generate go build -buildmode=c-shared -o libpich8.so .
+
+var (
+	// mu guards all state, the calls are serialized
+	mu         sync.Mutex
+	machines   = map[C.int]*chip8.Machine{}
+	nextHandle = C.int(1)
+	// lastErrors holds the last error of every handle, failed calls of pich8_create and calls with invalid handles use 0
+	lastErrors = map[C.int]string{}
+	version    = C.CString(chip8.Version)
+)
+
+func main() {}
+
+// fail stores the message of the given error for pich8_last_error and returns -1
+func fail(handle C.int, err error) C.int {
+	if _, ok := machines[handle]; !ok {
+		handle = 0
+	}
+	lastErrors[handle] = err.Error()
+	return -1
+}
+
+// recoverPanic stores a panic of the core as the last error of the machine and sets the result to -1,
+// so the panic doesn't end the host process. Every exported function defers it while holding the lock.
+func recoverPanic(handle C.int, result *C.int) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	code := fail(handle, fmt.Errorf("internal error: %v", r))
+	if result != nil {
+		*result = code
+	}
+}
+
+func machine(handle C.int) (*chip8.Machine, error) {
+	m, ok := machines[handle]
+	if !ok {
+		return nil, fmt.Errorf("invalid machine handle %v", handle)
+	}
+	return m, nil
+}
+
+// pich8_version returns the version of the API
+//
+//export pich8_version
+func pich8_version() *C.char {
+	return version
+}
+
+// pich8_last_error copies the message of the last error of the machine as null-terminated string to the buffer,
+// it's truncated if the buffer is too small. Errors of pich8_create and of invalid handles are stored for the handle 0.
+// Returns the length of the message without the null byte, which is 0 if there was no error.
+//
+//export pich8_last_error
+func pich8_last_error(handle C.int, buf *C.char, size C.int) (result C.int) {
+	mu.Lock()
+	defer mu.Unlock()
+	defer recoverPanic(handle, &result)
+
+	msg := lastErrors[handle]
+	if buf != nil && size > 0 {
+		cmsg := C.CString(msg)
+		defer C.free(unsafe.Pointer(cmsg))
+		C.copy_message(buf, size, cmsg)
+	}
+	return C.int(len(msg))
+}
+
+// pich8_create creates a machine and returns its handle.
+// The profile may be NULL for the default, a speed of 0 selects the default speed,
+// a negative seed seeds the random number generator with the current time.
+//
+//export pich8_create
+func pich8_create(profile *C.char, speed C.int, seed C.longlong) (result C.int) {
+	mu.Lock()
+	defer mu.Unlock()
+	defer recoverPanic(0, &result)
+
+	options := []chip8.Option{}
+	if profile != nil {
+		options = append(options, chip8.WithProfile(C.GoString(profile)))
+	}
+	if speed != 0 {
+		options = append(options, chip8.WithSpeed(int(speed)))
+	}
+	if seed >= 0 {
+		options = append(options, chip8.WithSeed(int64(seed)))
+	}
+	m, err := chip8.New(options...)
+	if err != nil {
+		return fail(0, err)
+	}
+
+	handle := nextHandle
+	nextHandle++
+	machines[handle] = m
+	return handle
+}
+
+// pich8_destroy releases the machine
+//
+//export pich8_destroy
+func pich8_destroy(handle C.int) {
+	mu.Lock()
+	defer mu.Unlock()
+	defer recoverPanic(handle, nil)
+	delete(machines, handle)
+	delete(lastErrors, handle)
+}
+
+// pich8_load_rom loads the ROM of the given size and resets the machine
+//
+//export pich8_load_rom
+func pich8_load_rom(handle C.int, rom *C.uchar, size C.int) (result C.int) {
+	mu.Lock()
+	defer mu.Unlock()
+	defer recoverPanic(handle, &result)
+
+	m, err := machine(handle)
+	if err != nil {
+		return fail(handle, err)
+	}
+	if rom == nil || size < 0 {
+		return fail(handle, errors.New("invalid ROM"))
+	}
+	if err := m.LoadROM(C.GoBytes(unsafe.Pointer(rom), size)); err != nil {
+		return fail(handle, err)
+	}
+	return 0
+}
+
+// pich8_reset restarts the loaded ROM
+//
+//export pich8_reset
+func pich8_reset(handle C.int) (result C.int) {
+	mu.Lock()
+	defer mu.Unlock()
+	defer recoverPanic(handle, &result)
+
+	m, err := machine(handle)
+	if err != nil {
+		return fail(handle, err)
+	}
+	if err := m.Reset(); err != nil {
+		return fail(handle, err)
+	}
+	return 0
+}
+
+// pich8_run_frame executes the instructions of one frame followed by a timer tick
+//
+//export pich8_run_frame
+func pich8_run_frame(handle C.int) (result C.int) {
+	mu.Lock()
+	defer mu.Unlock()
+	defer recoverPanic(handle, &result)
+
+	m, err := machine(handle)
+	if err != nil {
+		return fail(handle, err)
+	}
+	if err := m.RunFrame(); err != nil {
+		return fail(handle, err)
+	}
+	return 0
+}
+
+// pich8_step executes a single instruction without updating the timers
+//
+//export pich8_step
+func pich8_step(handle C.int) (result C.int) {
+	mu.Lock()
+	defer mu.Unlock()
+	defer recoverPanic(handle, &result)
+
+	m, err := machine(handle)
+	if err != nil {
+		return fail(handle, err)
+	}
+	if err := m.Step(); err != nil {
+		return fail(handle, err)
+	}
+	return 0
+}
+
+// pich8_set_keys sets the states of all keys, bit n of the mask is key n
+//
+//export pich8_set_keys
+func pich8_set_keys(handle C.int, mask C.ushort) (result C.int) {
+	mu.Lock()
+	defer mu.Unlock()
+	defer recoverPanic(handle, &result)
+
+	m, err := machine(handle)
+	if err != nil {
+		return fail(handle, err)
+	}
+	var keys [16]bool
+	for key := range keys {
+		keys[key] = mask&(1<<key) != 0
+	}
+	m.SetKeys(keys)
+	return 0
+}
+
+// pich8_framebuffer copies the pixels row by row to the buffer and stores the resolution,
+// every byte holds the first plane in bit 0 and the second plane in bit 1.
+// Returns the number of pixels, which is at most 128 * 64, or -1 if the buffer is too small.
+//
+//export pich8_framebuffer
+func pich8_framebuffer(handle C.int, buf *C.uchar, size C.int, width, height *C.int) (result C.int) {
+	mu.Lock()
+	defer mu.Unlock()
+	defer recoverPanic(handle, &result)
+
+	m, err := machine(handle)
+	if err != nil {
+		return fail(handle, err)
+	}
+	fb := m.Framebuffer()
+	if buf == nil || int(size) < len(fb.Pixels) {
+		return fail(handle, errors.New("framebuffer buffer too small"))
+	}
+	C.memcpy(unsafe.Pointer(buf), unsafe.Pointer(&fb.Pixels[0]), C.size_t(len(fb.Pixels)))
+	if width != nil {
+		*width = C.int(fb.Width)
+	}
+	if height != nil {
+		*height = C.int(fb.Height)
+	}
+	return C.int(len(fb.Pixels))
+}
+
+// pich8_audio returns the sound timer, the sound plays while it's above zero.
+// If the ROM set an XO-CHIP audio pattern, its 16 bytes are copied to the pattern buffer and hasPattern is set to 1,
+// otherwise a beep is played. The pattern and hasPattern may be NULL.
+//
+//export pich8_audio
+func pich8_audio(handle C.int, pattern *C.uchar, hasPattern *C.int) (result C.int) {
+	mu.Lock()
+	defer mu.Unlock()
+	defer recoverPanic(handle, &result)
+
+	m, err := machine(handle)
+	if err != nil {
+		return fail(handle, err)
+	}
+	audio := m.Audio()
+	if pattern != nil {
+		C.memcpy(unsafe.Pointer(pattern), unsafe.Pointer(&audio.Pattern[0]), C.size_t(len(audio.Pattern)))
+	}
+	if hasPattern != nil {
+		*hasPattern = 0
+		if audio.HasPattern {
+			*hasPattern = 1
+		}
+	}
+	return C.int(audio.SoundTimer)
+}
+
+// pich8_save_state copies a snapshot of the machine to the buffer and returns its size.
+// If the buffer is NULL or too small, nothing is copied, so the size can be queried first.
+//
+//export pich8_save_state
+func pich8_save_state(handle C.int, buf *C.uchar, size C.int) (result C.int) {
+	mu.Lock()
+	defer mu.Unlock()
+	defer recoverPanic(handle, &result)
+
+	m, err := machine(handle)
+	if err != nil {
+		return fail(handle, err)
+	}
+	snapshot, err := m.Snapshot()
+	if err != nil {
+		return fail(handle, err)
+	}
+	if buf != nil && int(size) >= len(snapshot) {
+		C.memcpy(unsafe.Pointer(buf), unsafe.Pointer(&snapshot[0]), C.size_t(len(snapshot)))
+	}
+	return C.int(len(snapshot))
+}
+
+// pich8_load_state restores a snapshot saved by pich8_save_state
+//
+//export pich8_load_state
+func pich8_load_state(handle C.int, buf *C.uchar, size C.int) (result C.int) {
+	mu.Lock()
+	defer mu.Unlock()
+	defer recoverPanic(handle, &result)
+
+	m, err := machine(handle)
+	if err != nil {
+		return fail(handle, err)
+	}
+	if buf == nil || size < 0 {
+		return fail(handle, errors.New("invalid snapshot"))
+	}
+	if err := m.Restore(C.GoBytes(unsafe.Pointer(buf), size)); err != nil {
+		return fail(handle, err)
+	}
+	return 0
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// TestABI builds the shared library and runs the C programs using it
+func TestABI(t *testing.T) {
+	if testing.Short() {
+		t.Skip("building the shared library takes a while")
+	}
+	if runtime.GOOS == "windows" {
+		t.Skip("the test program is linked with rpath")
+	}
+	cc := os.Getenv("CC")
+	if cc == "" {
+		cc = "cc"
+	}
+	if _, err := exec.LookPath(cc); err != nil {
+		t.Skip("no C compiler found")
+	}
+
+	dir, err := ioutil.TempDir("", "pich8-go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	run := func(t *testing.T, args ...string) {
+		if out, err := exec.Command(args[0], args[1:]...).CombinedOutput(); err != nil {
+			t.Fatalf("%v failed: %v\n%s", filepath.Base(args[0]), err, out)
+		}
+	}
+	run(t, filepath.Join(runtime.GOROOT(), "bin", "go"), "build", "-buildmode=c-shared", "-o", filepath.Join(dir, "libpich8.so"), ".")
+
+	// abi uses the whole API, wrap runs a ROM accessing the memory beyond its end
+	for _, program := range []string{"abi", "wrap"} {
+		t.Run(program, func(t *testing.T) {
+			exe := filepath.Join(dir, program)
+			run(t, cc, "-o", exe, filepath.Join("testdata", program+".c"), "-I"+dir, "-L"+dir, "-Wl,-rpath,"+dir, "-lpich8")
+			run(t, exe)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+// Exercises the C API of libpich8, it's built and run by TestABI
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+
+#include "libpich8.h"
+
+#define CHECK(cond) \
+	do { \
+		if (!(cond)) { \
+			fprintf(stderr, "%s:%d: %s failed\n", __FILE__, __LINE__, #cond); \
+			return 1; \
+		} \
+	} while (0)
+
+int main(void) {
+	char msg[256];
+	unsigned char pixels[128 * 64];
+	int width, height, has_pattern;
+
+	CHECK(strlen(pich8_version()) > 0);
+
+	// Errors of pich8_create are stored for the handle 0
+	CHECK(pich8_create("banana", 0, -1) == -1);
+	CHECK(pich8_last_error(0, msg, sizeof(msg)) > 0);
+	CHECK(strstr(msg, "banana") != NULL);
+
+	int m = pich8_create("chip8", 0, 1);
+	CHECK(m > 0);
+	CHECK(pich8_last_error(m, msg, sizeof(msg)) == 0);
+	CHECK(msg[0] == '\0');
+
+	// V0 = 5, ST = V0, I = font 0, V1 = 0, draw it at (0, 0) and loop
+	unsigned char rom[] = {0x60, 0x05, 0xF0, 0x18, 0xA0, 0x00, 0x61, 0x00, 0xD1, 0x15, 0x12, 0x0A};
+	CHECK(pich8_load_rom(m, rom, sizeof(rom)) == 0);
+	CHECK(pich8_run_frame(m) == 0);
+	CHECK(pich8_framebuffer(m, pixels, sizeof(pixels), &width, &height) == 64 * 32);
+	CHECK(width == 64 && height == 32);
+	CHECK(pixels[0] == 1 && pixels[4] == 0 && pixels[64] == 1 && pixels[65] == 0);
+	CHECK(pich8_audio(m, NULL, &has_pattern) == 4);
+	CHECK(has_pattern == 0);
+
+	// The size of a snapshot is queried with a NULL buffer
+	int size = pich8_save_state(m, NULL, 0);
+	CHECK(size > 0);
+	unsigned char *state = malloc(size);
+	CHECK(pich8_save_state(m, state, size) == size);
+	CHECK(pich8_run_frame(m) == 0);
+	CHECK(pich8_audio(m, NULL, NULL) == 3);
+	CHECK(pich8_load_state(m, state, size) == 0);
+	CHECK(pich8_audio(m, NULL, NULL) == 4);
+
+	// Errors are stored per machine and truncated to the buffer
+	CHECK(pich8_load_state(m, state, 10) == -1);
+	int len = pich8_last_error(m, msg, sizeof(msg));
+	CHECK(len > 0 && strstr(msg, "snapshot") != NULL);
+	CHECK(pich8_last_error(m, msg, 4) == len);
+	CHECK(strlen(msg) == 3);
+	CHECK(pich8_last_error(0, msg, sizeof(msg)) > 0 && strstr(msg, "banana") != NULL);
+	free(state);
+
+	// Invalid handles fail, also after destroying the machine
+	pich8_destroy(m);
+	CHECK(pich8_run_frame(m) == -1);
+	CHECK(pich8_last_error(0, msg, sizeof(msg)) > 0 && strstr(msg, "invalid machine handle") != NULL);
+
+	printf("ok\n");
+	return 0;
+}
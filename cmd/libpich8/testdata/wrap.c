@@ -0,0 +1,39 @@
+// Runs a ROM drawing a sprite from the top of the memory, which used to panic in the core, it's built and run by TestABI
+#include <stdio.h>
+#include <string.h>
+
+#include "libpich8.h"
+
+#define CHECK(cond) \
+	do { \
+		if (!(cond)) { \
+			fprintf(stderr, "%s:%d: %s failed\n", __FILE__, __LINE__, #cond); \
+			return 1; \
+		} \
+	} while (0)
+
+int main(void) {
+	char msg[256];
+	unsigned char pixels[128 * 64];
+	int width, height;
+
+	// I = 0xFFF8, draw 15 rows at (0, 0), the sprite wraps around to the font at the start of the memory
+	unsigned char rom[] = {0xF0, 0x00, 0xFF, 0xF8, 0xD0, 0x1F, 0x12, 0x06};
+	int m = pich8_create(NULL, 0, 0);
+	CHECK(m > 0);
+	CHECK(pich8_load_rom(m, rom, sizeof(rom)) == 0);
+	for (int i = 0; i < 3; i++) {
+		if (pich8_run_frame(m) != 0) {
+			pich8_last_error(m, msg, sizeof(msg));
+			fprintf(stderr, "pich8_run_frame failed: %s\n", msg);
+			return 1;
+		}
+	}
+	CHECK(pich8_framebuffer(m, pixels, sizeof(pixels), &width, &height) == 64 * 32);
+	CHECK(pixels[7 * 64] == 0 && pixels[8 * 64] == 1);
+	CHECK(pich8_last_error(m, msg, sizeof(msg)) == 0);
+	pich8_destroy(m);
+
+	printf("ok\n");
+	return 0;
+}
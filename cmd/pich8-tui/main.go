@@ -0,0 +1,170 @@
+// Command pich8-tui runs a ROM in the terminal, e.g. over SSH on a machine without X11.
+// It's a separate command as it doesn't link the window and audio device libraries:
+//
+//	go build ./cmd/pich8-tui
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/philw07/pich8-go/internal/capture"
+	"github.com/philw07/pich8-go/internal/config"
+	"github.com/philw07/pich8-go/internal/cpu"
+	"github.com/philw07/pich8-go/internal/emulator"
+	"github.com/philw07/pich8-go/internal/input"
+	"github.com/philw07/pich8-go/internal/movie"
+	"github.com/philw07/pich8-go/internal/palette"
+	"github.com/philw07/pich8-go/internal/romdb"
+	"github.com/philw07/pich8-go/internal/sound"
+	"github.com/philw07/pich8-go/internal/tui"
+)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		if err != flag.ErrHelp {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		os.Exit(1)
+	}
+}
+
+// run runs the ROM given in the command-line arguments in the terminal
+func run(args []string) error {
+	flags := flag.NewFlagSet("pich8-tui", flag.ContinueOnError)
+	configFile := flags.String("config", config.DefaultPath(), "settings file")
+	modeName := flags.String("mode", "halfblock", "characters the pixels are drawn with: halfblock or braille")
+	audio := flags.String("audio", "bell", "audio output: bell, null or wav")
+	wavFile := flags.String("wav", "pich8-go.wav", "file written by the wav audio output")
+	recordFile := flags.String("record", "", "record a movie of the session to the given file")
+	playFile := flags.String("play", "", "play the movie from the given file")
+	captureFile := flags.String("capture", "", "record a video of the session to the given GIF, APNG or Y4M file")
+	speed := flags.Int("speed", 0, "CPU speed in instructions per second (default from the settings)")
+	profile := flags.String("profile", "", "platform profile: pich8, chip8, schip or xochip (default from the settings)")
+	paletteName := flags.String("palette", "", "palette theme or colors (default from the settings)")
+	noDatabase := flags.Bool("nodb", false, "don't apply the settings of the ROM database and don't detect the quirks")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: pich8-tui [flags] rom.ch8")
+		flags.PrintDefaults()
+	}
+
+	// Allow flags before and after the ROM path
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() < 1 {
+		flags.Usage()
+		return errors.New("no ROM given")
+	}
+	file := flags.Arg(0)
+	if err := flags.Parse(flags.Args()[1:]); err != nil {
+		return err
+	}
+
+	// The settings given on the command line only apply to the session, the settings aren't saved
+	settings, err := config.Load(*configFile)
+	if err != nil {
+		return err
+	}
+	if *speed > 0 {
+		settings.Speed = *speed
+	}
+	if *profile != "" {
+		if settings.Quirks, err = cpu.ProfileQuirks(*profile); err != nil {
+			return err
+		}
+	}
+	themes, err := palette.LoadThemes(config.PalettesFile())
+	if err != nil {
+		return err
+	}
+	if *paletteName != "" {
+		if settings.Palette, err = palette.Parse(themes, *paletteName); err != nil {
+			return err
+		}
+	}
+	mode, err := tui.ParseMode(*modeName)
+	if err != nil {
+		return err
+	}
+	bindings, err := input.LoadBindings(settings.BindingsFile())
+	if err != nil {
+		return err
+	}
+	if *captureFile != "" {
+		if _, err := capture.FormatFromPath(*captureFile); err != nil {
+			return err
+		}
+	}
+
+	db := romdb.New()
+	if !*noDatabase {
+		if db, err = romdb.Load(settings.DatabaseDir()); err != nil {
+			return err
+		}
+		if err := db.LoadOverrides(config.RomOverridesFile()); err != nil {
+			return err
+		}
+	}
+
+	var playMovie *movie.Movie
+	if *playFile != "" {
+		if playMovie, err = movie.Load(*playFile); err != nil {
+			return err
+		}
+	}
+
+	// The bell rings in addition to the audio player, which keeps the audio settings
+	var sink sound.Sink
+	switch *audio {
+	case "bell", "null":
+		sink = sound.NewNullSink()
+	case "wav":
+		if sink, err = sound.NewWavSink(*wavFile); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown audio output %q", *audio)
+	}
+	var player emulator.Audio = sound.NewAudioPlayer(sink)
+
+	term, err := tui.Open(os.Stdin, os.Stdout)
+	if err != nil {
+		player.Close()
+		return err
+	}
+	defer term.Close()
+
+	if *audio == "bell" {
+		player = tui.NewBell(term, player)
+	}
+	frontend := tui.NewFrontend(term, mode, filepath.Base(file))
+	emu, err := emulator.NewEmulator(settings, bindings, emulator.Frontend{Display: frontend, Input: frontend, Audio: player})
+	if err != nil {
+		player.Close()
+		return err
+	}
+	emu.SetDatabase(db)
+	emu.SetDetection(!*noDatabase)
+	emu.SetExplicit(emulator.ExplicitSettings{Speed: *speed > 0, Quirks: *profile != "", Palette: *paletteName != ""})
+	emu.SetThemes(themes)
+	err = emu.LoadRomFile(file)
+	if err == nil && playMovie != nil {
+		err = emu.PlayMovie(playMovie)
+	}
+	if err == nil && *recordFile != "" {
+		err = emu.StartRecording(*recordFile)
+	}
+	if err == nil && *captureFile != "" {
+		err = emu.StartCapture(*captureFile)
+	}
+	if err != nil {
+		player.Close()
+		return err
+	}
+	emu.Run()
+	return nil
+}
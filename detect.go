@@ -0,0 +1,31 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/philw07/pich8-go/internal/detect"
+)
+
+// runDetect analyzes a ROM and prints the proposed platform profile and quirks
+func runDetect(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: pich8-go detect rom.ch8")
+	}
+
+	rom, err := ioutil.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+
+	res := detect.Analyze(rom)
+	fmt.Printf("Profile:    %v\n", res.Profile)
+	fmt.Printf("Confidence: %.0f%%\n", res.Confidence*100)
+	fmt.Printf("Quirks:     load/store=%v shift=%v jump=%v vf order=%v draw=%v\n",
+		res.Quirks.LoadStore, res.Quirks.Shift, res.Quirks.Jump, res.Quirks.VfOrder, res.Quirks.Draw)
+	for _, reason := range res.Reasons {
+		fmt.Printf("  - %v\n", reason)
+	}
+	return nil
+}
@@ -4,8 +4,9 @@ go 1.15
 
 require (
 	github.com/faiface/beep v1.1.0
+	github.com/faiface/mainthread v0.0.0-20171120011319-8b78f0a41ae3
 	github.com/faiface/pixel v0.10.0
-	github.com/go-gl/glfw/v3.3/glfw v0.0.0-20201108214237-06ea97f0c265 // indirect
+	github.com/go-gl/glfw/v3.3/glfw v0.0.0-20201108214237-06ea97f0c265
 	github.com/go-gl/mathgl v1.0.0 // indirect
 	github.com/kr/text v0.2.0 // indirect
 	github.com/sqweek/dialog v0.0.0-20200911184034-8a3d98e8211d
@@ -0,0 +1,389 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/philw07/pich8-go/internal/capture"
+	"github.com/philw07/pich8-go/internal/config"
+	"github.com/philw07/pich8-go/internal/cpu"
+	"github.com/philw07/pich8-go/internal/emulator"
+	"github.com/philw07/pich8-go/internal/gui"
+	"github.com/philw07/pich8-go/internal/input"
+	"github.com/philw07/pich8-go/internal/movie"
+	"github.com/philw07/pich8-go/internal/palette"
+	"github.com/philw07/pich8-go/internal/romdb"
+	"github.com/philw07/pich8-go/internal/sound"
+	"github.com/philw07/pich8-go/internal/sound/speaker"
+	"github.com/philw07/pich8-go/internal/video"
+)
+
+// guiOptions holds the command-line options of the emulator window
+type guiOptions struct {
+	flags *flag.FlagSet
+
+	audio      string
+	wavFile    string
+	configFile string
+	romFile    string
+	recordFile string
+	playFile   string
+	capture    string
+	scale      int
+	seed       int64
+	seeded     bool
+	paused     bool
+	noDatabase bool
+
+	// Settings which only apply to the session
+	overrides config.Settings
+	profile   string
+	palette   string
+	scaling   string
+	scaler    string
+	border    string
+	themes    []palette.Theme
+}
+
+// newGuiOptions creates the options with their command-line flags
+func newGuiOptions() *guiOptions {
+	opts := guiOptions{flags: flag.NewFlagSet("pich8-go", flag.ContinueOnError)}
+	flags := opts.flags
+	flags.StringVar(&opts.audio, "audio", "speaker", "audio output: speaker, null or wav")
+	flags.StringVar(&opts.wavFile, "wav", "pich8-go.wav", "file written by the wav audio output")
+	flags.StringVar(&opts.configFile, "config", config.DefaultPath(), "settings file")
+	flags.StringVar(&opts.recordFile, "record", "", "record a movie of the session to the given file")
+	flags.StringVar(&opts.playFile, "play", "", "play the movie from the given file, requires the ROM")
+	flags.StringVar(&opts.capture, "capture", "", "record a video of the session to the given GIF, APNG or Y4M file")
+	flags.IntVar(&opts.scale, "scale", 0, "window size as multiple of the CHIP-8 resolution")
+	flags.Int64Var(&opts.seed, "seed", 0, "seed of the random number generator (default current time)")
+	flags.BoolVar(&opts.paused, "paused", false, "start paused")
+	flags.BoolVar(&opts.noDatabase, "nodb", false, "don't apply the settings of the ROM database and don't detect the quirks")
+
+	overrides := &opts.overrides
+	flags.StringVar(&overrides.Bindings, "bindings", "", "key bindings file (default bindings.json in the config directory)")
+	flags.IntVar(&overrides.Speed, "speed", 0, "CPU speed in instructions per second")
+	flags.StringVar(&opts.profile, "profile", "", "platform profile: pich8, chip8, schip or xochip")
+	flags.BoolVar(&overrides.Quirks.LoadStore, "quirk-loadstore", false, "load/store quirk, FX55 and FX65 don't change I")
+	flags.BoolVar(&overrides.Quirks.Shift, "quirk-shift", false, "shift quirk, 8XY6 and 8XYE shift VX instead of VY")
+	flags.BoolVar(&overrides.Quirks.Jump, "quirk-jump", false, "jump quirk, BNNN jumps to XNN + VX")
+	flags.BoolVar(&overrides.Quirks.VfOrder, "quirk-vforder", false, "VF order quirk, VF is written after the result register by 8XYN")
+	flags.BoolVar(&overrides.Quirks.Draw, "quirk-draw", false, "draw quirk, DXY0 draws 16x16 sprites in low resolution")
+	flags.StringVar(&opts.palette, "palette", "", "palette theme or colors of the background, first plane, second plane and both planes, e.g. amber or #000000,#ffffff,#a8a8a8,#545454")
+	flags.StringVar(&opts.scaling, "scaling", "", "scale mode: stretch, aspect or integer")
+	flags.StringVar(&opts.border, "border", "", "color of the border around the framebuffer, e.g. #202020")
+	flags.StringVar(&opts.scaler, "scaler", "", "pixel-art scaler: none, scale2x, scale3x, scale4x, eagle or hq2x")
+	flags.Float64Var(&overrides.Filters.Persistence, "persistence", 0, "strength of the phosphor persistence between 0 and 1, reduces flicker")
+	flags.Float64Var(&overrides.Filters.Scanlines, "scanlines", 0, "strength of the scanlines between 0 and 1")
+	flags.Float64Var(&overrides.Filters.Grid, "grid", 0, "strength of the pixel grid between 0 and 1")
+	flags.IntVar(&overrides.ScreenshotScale, "screenshot-scale", 1, "size of a CHIP-8 pixel in screenshots")
+	flags.IntVar(&overrides.CaptureScale, "capture-scale", 1, "size of a pixel in video recordings")
+	flags.BoolVar(&overrides.VSync, "vsync", false, "enable VSync")
+	flags.BoolVar(&overrides.Fullscreen, "fullscreen", false, "start in fullscreen")
+	flags.Float64Var(&overrides.Volume, "volume", 0, "volume between 0 and 1")
+	flags.BoolVar(&overrides.Muted, "mute", false, "mute the sound")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), `Usage: pich8-go [flags] [rom.ch8]
+       pich8-go bench [flags] rom.ch8     Benchmark a ROM without window
+       pich8-go replay movie rom.ch8      Replay a movie without window and verify it
+       pich8-go detect rom.ch8            Propose the platform profile and quirks of a ROM
+       pich8-go help                      Show this help
+
+Flags:`)
+		flags.PrintDefaults()
+	}
+
+	return &opts
+}
+
+// parseGuiFlags parses the command-line arguments of the emulator window
+func parseGuiFlags(args []string) (*guiOptions, error) {
+	opts := newGuiOptions()
+	flags := opts.flags
+	overrides := &opts.overrides
+
+	// Allow flags before and after the ROM path
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if flags.NArg() > 0 {
+		opts.romFile = flags.Arg(0)
+		if err := flags.Parse(flags.Args()[1:]); err != nil {
+			return nil, err
+		}
+		if flags.NArg() > 0 {
+			flags.Usage()
+			return nil, fmt.Errorf("unexpected argument %q", flags.Arg(0))
+		}
+	}
+
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			opts.seeded = true
+		}
+	})
+
+	themes, err := palette.LoadThemes(config.PalettesFile())
+	if err != nil {
+		return nil, err
+	}
+	opts.themes = themes
+
+	// The single quirks are applied on top of the profile
+	if opts.profile != "" {
+		quirks, err := cpu.ProfileQuirks(opts.profile)
+		if err != nil {
+			return nil, err
+		}
+		flags.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "quirk-loadstore":
+				quirks.LoadStore = overrides.Quirks.LoadStore
+			case "quirk-shift":
+				quirks.Shift = overrides.Quirks.Shift
+			case "quirk-jump":
+				quirks.Jump = overrides.Quirks.Jump
+			case "quirk-vforder":
+				quirks.VfOrder = overrides.Quirks.VfOrder
+			case "quirk-draw":
+				quirks.Draw = overrides.Quirks.Draw
+			}
+		})
+		overrides.Quirks = quirks
+	}
+	if opts.palette != "" {
+		p, err := palette.Parse(opts.themes, opts.palette)
+		if err != nil {
+			return nil, err
+		}
+		overrides.Palette = p
+	}
+	if opts.scaling != "" {
+		mode, err := video.ParseScaleMode(opts.scaling)
+		if err != nil {
+			return nil, err
+		}
+		overrides.Scaling = mode
+	}
+	if opts.scaler != "" {
+		scaler, err := video.ParseScaler(opts.scaler)
+		if err != nil {
+			return nil, err
+		}
+		overrides.Scaler = scaler
+	}
+	if opts.border != "" {
+		c, err := palette.ParseColor(opts.border)
+		if err != nil {
+			return nil, err
+		}
+		overrides.Border = c
+	}
+	if err := overrides.Filters.Validate(); err != nil {
+		return nil, err
+	}
+	if overrides.ScreenshotScale < 1 {
+		return nil, fmt.Errorf("invalid screenshot scale %v", overrides.ScreenshotScale)
+	}
+	if overrides.CaptureScale < 1 {
+		return nil, fmt.Errorf("invalid capture scale %v", overrides.CaptureScale)
+	}
+	if opts.capture != "" {
+		if _, err := capture.FormatFromPath(opts.capture); err != nil {
+			return nil, err
+		}
+	}
+	if opts.scale < 0 {
+		return nil, fmt.Errorf("invalid scale %v", opts.scale)
+	}
+
+	return opts, nil
+}
+
+// applyOverrides copies the settings given on the command line from src to dst
+func (opts *guiOptions) applyOverrides(dst, src *config.Settings) {
+	opts.flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "bindings":
+			dst.Bindings = src.Bindings
+		case "speed":
+			dst.Speed = src.Speed
+		case "profile":
+			dst.Quirks = src.Quirks
+		case "quirk-loadstore":
+			dst.Quirks.LoadStore = src.Quirks.LoadStore
+		case "quirk-shift":
+			dst.Quirks.Shift = src.Quirks.Shift
+		case "quirk-jump":
+			dst.Quirks.Jump = src.Quirks.Jump
+		case "quirk-vforder":
+			dst.Quirks.VfOrder = src.Quirks.VfOrder
+		case "quirk-draw":
+			dst.Quirks.Draw = src.Quirks.Draw
+		case "palette":
+			dst.Palette = src.Palette
+		case "scaling":
+			dst.Scaling = src.Scaling
+		case "border":
+			dst.Border = src.Border
+		case "scaler":
+			dst.Scaler = src.Scaler
+		case "persistence":
+			dst.Filters.Persistence = src.Filters.Persistence
+		case "scanlines":
+			dst.Filters.Scanlines = src.Filters.Scanlines
+		case "grid":
+			dst.Filters.Grid = src.Filters.Grid
+		case "screenshot-scale":
+			dst.ScreenshotScale = src.ScreenshotScale
+		case "capture-scale":
+			dst.CaptureScale = src.CaptureScale
+		case "vsync":
+			dst.VSync = src.VSync
+		case "fullscreen":
+			dst.Fullscreen = src.Fullscreen
+		case "scale":
+			dst.Window = src.Window
+		case "volume":
+			dst.Volume = src.Volume
+		case "mute":
+			dst.Muted = src.Muted
+		}
+	})
+}
+
+// explicitSettings returns the settings given on the command line which the ROM database doesn't replace
+func (opts *guiOptions) explicitSettings() emulator.ExplicitSettings {
+	var explicit emulator.ExplicitSettings
+	opts.flags.Visit(func(f *flag.Flag) {
+		switch {
+		case f.Name == "speed":
+			explicit.Speed = true
+		case f.Name == "profile", strings.HasPrefix(f.Name, "quirk-"):
+			explicit.Quirks = true
+		case f.Name == "palette":
+			explicit.Palette = true
+		}
+	})
+	return explicit
+}
+
+// runGui runs the emulator window with the given command-line arguments
+func runGui(args []string) error {
+	opts, err := parseGuiFlags(args)
+	if err != nil {
+		return err
+	}
+
+	settings, err := config.Load(opts.configFile)
+	if err != nil {
+		return err
+	}
+	session := *settings
+	opts.applyOverrides(&session, &opts.overrides)
+	if session.Speed <= 0 {
+		return fmt.Errorf("invalid speed %v", session.Speed)
+	}
+
+	bindings, err := input.LoadBindings(session.BindingsFile())
+	if err != nil {
+		return err
+	}
+
+	db := romdb.New()
+	if !opts.noDatabase {
+		if db, err = romdb.Load(session.DatabaseDir()); err != nil {
+			return err
+		}
+		if err := db.LoadOverrides(config.RomOverridesFile()); err != nil {
+			return err
+		}
+	}
+
+	var playMovie *movie.Movie
+	if opts.playFile != "" {
+		if opts.romFile == "" {
+			return fmt.Errorf("playing a movie requires the ROM")
+		}
+		if playMovie, err = movie.Load(opts.playFile); err != nil {
+			return err
+		}
+	}
+
+	sink, err := openAudioSink(opts.audio, opts.wavFile)
+	if err != nil {
+		return err
+	}
+
+	pixelgl.Run(func() {
+		player := sound.NewAudioPlayer(sink)
+		var emu *emulator.Emulator
+		disp, err := gui.NewDisplay(bindings.HelpLines(), &session)
+		if err == nil {
+			if opts.scale > 0 {
+				disp.SetScale(float64(opts.scale))
+			}
+			frontend := emulator.Frontend{Display: disp, Input: disp, Audio: player}
+			emu, err = emulator.NewEmulator(&session, bindings, frontend)
+		}
+		if err == nil {
+			emu.SetDatabase(db)
+			emu.SetDetection(!opts.noDatabase)
+			emu.SetExplicit(opts.explicitSettings())
+			emu.SetThemes(opts.themes)
+			if opts.seeded {
+				emu.SetSeed(opts.seed)
+			}
+		}
+		if err == nil && opts.romFile != "" {
+			err = emu.LoadRomFile(opts.romFile)
+		}
+		if err == nil && playMovie != nil {
+			err = emu.PlayMovie(playMovie)
+		}
+		if err == nil && opts.recordFile != "" {
+			err = emu.StartRecording(opts.recordFile)
+		}
+		if err == nil && opts.capture != "" {
+			err = emu.StartCapture(opts.capture)
+		}
+		if err != nil {
+			player.Close()
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		emu.SetPaused(opts.paused)
+		emu.Run()
+
+		// Overrides only apply to the session and aren't saved
+		final := emu.Settings()
+		opts.applyOverrides(final, settings)
+		if err := final.Save(opts.configFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to save settings: %v\n", err)
+		}
+	})
+	return nil
+}
+
+// openAudioSink opens the audio output with the given name,
+// if no audio device is available the emulator continues without sound
+func openAudioSink(name, wavFile string) (sound.Sink, error) {
+	switch name {
+	case "speaker":
+		sink, err := speaker.NewSink()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Audio device unavailable, continuing without sound: %v\n", err)
+			return sound.NewNullSink(), nil
+		}
+		return sink, nil
+	case "null":
+		return sound.NewNullSink(), nil
+	case "wav":
+		return sound.NewWavSink(wavFile)
+	default:
+		return nil, fmt.Errorf("unknown audio output %q", name)
+	}
+}
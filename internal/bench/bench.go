@@ -0,0 +1,146 @@
+package bench
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/philw07/pich8-go/internal/cpu"
+)
+
+// Result holds the measurements of a benchmark run
+type Result struct {
+	Duration      time.Duration
+	Instructions  int
+	KeyWaitCycles int
+	Frames        int
+	Allocs        uint64
+
+	// Executed drawing and scrolling instructions
+	DrawInstructions   int
+	ScrollInstructions int
+
+	// Time spent in the drawing and scrolling instructions, Other is the remaining time.
+	// It includes the other instructions as well as the overhead of the loop, the timers and the time measurement.
+	Draw   time.Duration
+	Scroll time.Duration
+	Other  time.Duration
+}
+
+// Run executes the given ROM flat-out for the given duration without rendering or audio.
+// The timers are updated every instructionsPerFrame instructions.
+func Run(rom []byte, duration time.Duration, instructionsPerFrame int) (*Result, error) {
+	if instructionsPerFrame <= 0 {
+		return nil, fmt.Errorf("invalid number of instructions per frame: %v", instructionsPerFrame)
+	}
+
+	c := cpu.NewCPU()
+	if err := c.LoadRom(rom); err != nil {
+		return nil, err
+	}
+
+	var res Result
+	var keys [16]bool
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	mallocs := memStats.Mallocs
+
+	start := time.Now()
+	for time.Since(start) < duration {
+		for i := 0; i < instructionsPerFrame; i++ {
+			kind := otherOpcode
+			if c.WaitingForKey() {
+				res.KeyWaitCycles++
+			} else {
+				kind = opcodeKind(c.NextOpcode())
+			}
+
+			// Only time drawing and scrolling individually to keep the overhead low
+			switch kind {
+			case drawOpcode, scrollOpcode:
+				t := time.Now()
+				if err := c.Tick(keys); err != nil {
+					return nil, err
+				}
+				if kind == drawOpcode {
+					res.Draw += time.Since(t)
+					res.DrawInstructions++
+				} else {
+					res.Scroll += time.Since(t)
+					res.ScrollInstructions++
+				}
+			default:
+				if err := c.Tick(keys); err != nil {
+					return nil, err
+				}
+			}
+		}
+		c.UpdateTimers()
+
+		res.Instructions += instructionsPerFrame
+		res.Frames++
+	}
+	res.Duration = time.Since(start)
+
+	runtime.ReadMemStats(&memStats)
+	res.Allocs = memStats.Mallocs - mallocs
+	res.Other = res.Duration - res.Draw - res.Scroll
+
+	return &res, nil
+}
+
+// InstructionsPerSecond returns the number of emulated instructions per second
+func (res *Result) InstructionsPerSecond() float64 {
+	return float64(res.Instructions) / res.Duration.Seconds()
+}
+
+// AllocsPerFrame returns the average number of heap allocations per frame
+func (res *Result) AllocsPerFrame() float64 {
+	if res.Frames == 0 {
+		return 0
+	}
+	return float64(res.Allocs) / float64(res.Frames)
+}
+
+func (res *Result) String() string {
+	share := func(d time.Duration) float64 {
+		return d.Seconds() / res.Duration.Seconds() * 100
+	}
+
+	return fmt.Sprintf(`Duration:          %v
+Instructions:      %v (%v waiting for key)
+Frames:            %v
+Speed:             %.3f MIPS
+Allocations:       %.2f per frame
+Draw:              %v (%.1f%%, %v instructions)
+Scroll:            %v (%.1f%%, %v instructions)
+Other:             %v (%.1f%%, other instructions and loop overhead)`,
+		res.Duration.Round(time.Millisecond),
+		res.Instructions, res.KeyWaitCycles,
+		res.Frames,
+		res.InstructionsPerSecond()/1_000_000,
+		res.AllocsPerFrame(),
+		res.Draw.Round(time.Millisecond), share(res.Draw), res.DrawInstructions,
+		res.Scroll.Round(time.Millisecond), share(res.Scroll), res.ScrollInstructions,
+		res.Other.Round(time.Millisecond), share(res.Other),
+	)
+}
+
+type kind byte
+
+const (
+	otherOpcode kind = iota
+	drawOpcode
+	scrollOpcode
+)
+
+func opcodeKind(opcode uint16) kind {
+	switch {
+	case opcode&0xF000 == 0xD000, opcode == 0x00E0, opcode == 0x0230:
+		return drawOpcode
+	case opcode&0xFFF0 == 0x00C0, opcode&0xFFF0 == 0x00D0, opcode == 0x00FB, opcode == 0x00FC:
+		return scrollOpcode
+	default:
+		return otherOpcode
+	}
+}
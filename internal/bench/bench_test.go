@@ -0,0 +1,69 @@
+package bench
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	assert := assert.New(t)
+
+	// Draw a sprite, scroll down and loop
+	rom := []byte{
+		0xA2, 0x0A, // I = 0x20A
+		0xD0, 0x15, // draw(V0, V1, 5)
+		0x00, 0xC1, // scroll down 1
+		0x00, 0xFB, // scroll right
+		0x12, 0x02, // goto 0x202
+		0xF0, 0x90, 0x90, 0x90, 0xF0,
+	}
+
+	res, err := Run(rom, 50*time.Millisecond, 12)
+	assert.Nil(err)
+	assert.True(res.Frames > 0)
+	assert.EqualValues(res.Frames*12, res.Instructions)
+	// After the first instruction, the loop of four instructions draws once and scrolls twice,
+	// so the 12 instructions of a frame contain three draws and six scrolls
+	assert.Equal(res.Frames*3, res.DrawInstructions)
+	assert.Equal(res.Frames*6, res.ScrollInstructions)
+	assert.True(res.Draw > 0)
+	assert.True(res.Scroll > 0)
+
+	// Neither drawing nor scrolling, the waiting for a key isn't counted as instructions of a kind
+	res, err = Run([]byte{0xF0, 0x0A, 0x12, 0x00}, 10*time.Millisecond, 12)
+	assert.Nil(err)
+	assert.Equal(res.Instructions-1, res.KeyWaitCycles)
+	assert.Zero(res.DrawInstructions)
+	assert.Zero(res.ScrollInstructions)
+	assert.Zero(res.Draw)
+	assert.Zero(res.Scroll)
+
+	_, err = Run(rom, time.Millisecond, 0)
+	assert.NotNil(err)
+}
+
+func TestResult(t *testing.T) {
+	assert := assert.New(t)
+
+	res := Result{Duration: 2 * time.Second, Instructions: 3_000_000, Frames: 100, Allocs: 50, Draw: time.Second}
+	assert.Equal(1_500_000.0, res.InstructionsPerSecond())
+	assert.Equal(0.5, res.AllocsPerFrame())
+	assert.Contains(res.String(), "1.500 MIPS")
+	assert.Contains(res.String(), "Draw:              1s (50.0%")
+	assert.Zero((&Result{}).AllocsPerFrame())
+}
+
+func TestOpcodeKind(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(drawOpcode, opcodeKind(0xD125))
+	assert.Equal(drawOpcode, opcodeKind(0x00E0))
+	assert.Equal(scrollOpcode, opcodeKind(0x00C4))
+	assert.Equal(scrollOpcode, opcodeKind(0x00D2))
+	assert.Equal(scrollOpcode, opcodeKind(0x00FB))
+	assert.Equal(scrollOpcode, opcodeKind(0x00FC))
+	assert.Equal(otherOpcode, opcodeKind(0x00EE))
+	assert.Equal(otherOpcode, opcodeKind(0x6012))
+}
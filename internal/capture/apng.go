@@ -0,0 +1,203 @@
+package capture
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"io"
+	"math"
+	"os"
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// apngRecorder writes the frames to an animated PNG, identical frames are merged.
+// The frames are encoded with image/png and their image data is moved to the animation chunks.
+type apngRecorder struct {
+	file    *os.File
+	writer  *bufio.Writer
+	canvas  canvas
+	encoder png.Encoder
+
+	// Offset of the acTL chunk, its number of frames is updated on closing
+	actlOffset int64
+	sequence   uint32
+	numFrames  uint32
+
+	// The image data of the frame which is shown until the next different frame
+	pending       []byte
+	pendingFrames int
+}
+
+func newApngRecorder(path string) (*apngRecorder, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &apngRecorder{
+		file:    file,
+		writer:  bufio.NewWriter(file),
+		encoder: png.Encoder{CompressionLevel: png.BestSpeed},
+	}, nil
+}
+
+func (r *apngRecorder) Frame(img *image.RGBA) error {
+	img, same := r.canvas.next(img)
+	if same {
+		r.pendingFrames++
+		return nil
+	}
+
+	header, data, err := r.encode(img)
+	if err != nil {
+		return err
+	}
+	if r.pending == nil && r.numFrames == 0 {
+		if err := r.writeHeader(header); err != nil {
+			return err
+		}
+	} else if err := r.flush(); err != nil {
+		return err
+	}
+	r.pending = data
+	r.pendingFrames = 1
+	return nil
+}
+
+// encode encodes the image and returns the data of the IHDR chunk and the image data
+func (r *apngRecorder) encode(img image.Image) ([]byte, []byte, error) {
+	var buf bytes.Buffer
+	if err := r.encoder.Encode(&buf, img); err != nil {
+		return nil, nil, err
+	}
+
+	var header, data []byte
+	chunks := buf.Bytes()[len(pngSignature):]
+	for len(chunks) >= 12 {
+		length := binary.BigEndian.Uint32(chunks)
+		typ := string(chunks[4:8])
+		content := chunks[8 : 8+length]
+		switch typ {
+		case "IHDR":
+			header = content
+		case "IDAT":
+			data = append(data, content...)
+		}
+		chunks = chunks[12+length:]
+	}
+	return header, data, nil
+}
+
+func (r *apngRecorder) writeHeader(header []byte) error {
+	if _, err := r.writer.Write(pngSignature); err != nil {
+		return err
+	}
+	if err := writeChunk(r.writer, "IHDR", header); err != nil {
+		return err
+	}
+	r.actlOffset = int64(len(pngSignature) + 12 + len(header))
+	return writeChunk(r.writer, "acTL", actl(0))
+}
+
+// actl returns the data of the acTL chunk for the given number of frames, played in an endless loop
+func actl(numFrames uint32) []byte {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint32(data, numFrames)
+	return data
+}
+
+// flush writes the pending frame
+func (r *apngRecorder) flush() error {
+	if r.pending == nil {
+		return nil
+	}
+
+	delay := r.pendingFrames
+	if delay > math.MaxUint16 {
+		delay = math.MaxUint16
+	}
+	w, h := r.canvas.cur.Rect.Dx(), r.canvas.cur.Rect.Dy()
+	fctl := make([]byte, 26)
+	binary.BigEndian.PutUint32(fctl[0:], r.sequence)
+	binary.BigEndian.PutUint32(fctl[4:], uint32(w))
+	binary.BigEndian.PutUint32(fctl[8:], uint32(h))
+	binary.BigEndian.PutUint16(fctl[20:], uint16(delay))
+	binary.BigEndian.PutUint16(fctl[22:], FrameRate)
+	// Offset, dispose and blend operations are zero, the frames replace the whole image
+	if err := writeChunk(r.writer, "fcTL", fctl); err != nil {
+		return err
+	}
+	r.sequence++
+
+	// The first frame is the default image, which is shown by viewers without APNG support
+	if r.numFrames == 0 {
+		if err := writeChunk(r.writer, "IDAT", r.pending); err != nil {
+			return err
+		}
+	} else {
+		data := make([]byte, 4+len(r.pending))
+		binary.BigEndian.PutUint32(data, r.sequence)
+		copy(data[4:], r.pending)
+		if err := writeChunk(r.writer, "fdAT", data); err != nil {
+			return err
+		}
+		r.sequence++
+	}
+
+	r.numFrames++
+	r.pending = nil
+	return nil
+}
+
+func (r *apngRecorder) Close() error {
+	defer r.file.Close()
+
+	if err := r.flush(); err != nil {
+		return err
+	}
+	if r.numFrames == 0 {
+		return errors.New("no frames recorded")
+	}
+	if err := writeChunk(r.writer, "IEND", nil); err != nil {
+		return err
+	}
+	if err := r.writer.Flush(); err != nil {
+		return err
+	}
+
+	// Update the number of frames
+	if _, err := r.file.Seek(r.actlOffset, io.SeekStart); err != nil {
+		return err
+	}
+	if err := writeChunk(r.file, "acTL", actl(r.numFrames)); err != nil {
+		return err
+	}
+	return r.file.Close()
+}
+
+func writeChunk(w io.Writer, typ string, data []byte) error {
+	if len(typ) != 4 {
+		return fmt.Errorf("invalid chunk type %q", typ)
+	}
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header, uint32(len(data)))
+	copy(header[4:], typ)
+
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(data)
+	footer := make([]byte, 4)
+	binary.BigEndian.PutUint32(footer, crc.Sum32())
+
+	for _, b := range [][]byte{header, data, footer} {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+// Package capture records the emulated frames to animated images and videos
+package capture
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FrameRate is the number of frames per second passed to a Recorder
+const FrameRate = 60
+
+// Format is the file format of a recording
+type Format int
+
+const (
+	// GIF is an animated GIF, frames shorter than 1/50s are dropped as GIF delays are in 1/100s
+	GIF Format = iota
+	// APNG is an animated PNG
+	APNG
+	// Y4M is an uncompressed YUV4MPEG2 video, it's recorded along with a WAV file of the audio
+	Y4M
+)
+
+// Formats contains all formats
+var Formats = [...]Format{GIF, APNG, Y4M}
+
+var formatNames = [...]string{"gif", "apng", "y4m"}
+var formatExtensions = [...]string{".gif", ".png", ".y4m"}
+
+func (f Format) String() string {
+	if f < 0 || int(f) >= len(formatNames) {
+		return "invalid"
+	}
+	return formatNames[f]
+}
+
+// Extension returns the file extension of the format
+func (f Format) Extension() string {
+	if f < 0 || int(f) >= len(formatExtensions) {
+		return ""
+	}
+	return formatExtensions[f]
+}
+
+// ParseFormat parses the name of a format
+func ParseFormat(s string) (Format, error) {
+	for _, f := range Formats {
+		if f.String() == s {
+			return f, nil
+		}
+	}
+	return GIF, fmt.Errorf("unknown recording format %q", s)
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (f Format) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (f *Format) UnmarshalText(text []byte) error {
+	parsed, err := ParseFormat(string(text))
+	if err != nil {
+		return err
+	}
+	*f = parsed
+	return nil
+}
+
+// FormatFromPath returns the format of the given file by its extension
+func FormatFromPath(path string) (Format, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".apng" {
+		return APNG, nil
+	}
+	for _, f := range Formats {
+		if f.Extension() == ext {
+			return f, nil
+		}
+	}
+	return GIF, fmt.Errorf("unknown recording format of %v, use .gif, .png, .apng or .y4m", path)
+}
+
+// Recorder writes a stream of frames with FrameRate frames per second to a file
+type Recorder interface {
+	// Frame adds the next frame, the recorder doesn't keep a reference to the image
+	Frame(img *image.RGBA) error
+	// Close finishes the file
+	Close() error
+}
+
+// Create creates a recorder writing the given format to the given file, the directory is created if needed
+func Create(path string, format Format) (Recorder, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	switch format {
+	case GIF:
+		return newGifRecorder(path)
+	case APNG:
+		return newApngRecorder(path)
+	case Y4M:
+		return newY4mRecorder(path)
+	}
+	return nil, fmt.Errorf("unknown recording format %v", format)
+}
+
+// canvas keeps the size of the first frame, later frames of another size, e.g. after switching the resolution, are scaled to it
+type canvas struct {
+	cur, prev *image.RGBA
+	started   bool
+}
+
+// next copies the frame to the canvas and returns it along with whether it's the same as the previous frame.
+// The returned image is valid until the next call.
+func (c *canvas) next(src *image.RGBA) (*image.RGBA, bool) {
+	if c.cur == nil {
+		rect := image.Rect(0, 0, src.Rect.Dx(), src.Rect.Dy())
+		c.cur = image.NewRGBA(rect)
+		c.prev = image.NewRGBA(rect)
+	}
+	c.cur, c.prev = c.prev, c.cur
+
+	w, h := c.cur.Rect.Dx(), c.cur.Rect.Dy()
+	sw, sh := src.Rect.Dx(), src.Rect.Dy()
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			col := src.RGBAAt(src.Rect.Min.X+x*sw/w, src.Rect.Min.Y+y*sh/h)
+			// The formats don't support transparency
+			col.A = 0xff
+			c.cur.SetRGBA(x, y, col)
+		}
+	}
+
+	same := c.started && bytes.Equal(c.cur.Pix, c.prev.Pix)
+	c.started = true
+	return c.cur, same
+}
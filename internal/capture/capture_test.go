@@ -0,0 +1,150 @@
+package capture
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newFrame(w, h int, c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+	}
+	return img
+}
+
+var (
+	black = color.RGBA{0, 0, 0, 255}
+	white = color.RGBA{255, 255, 255, 255}
+)
+
+// record writes the given frames, each repeated the given number of times, and returns the file content
+func record(t *testing.T, format Format, frames []*image.RGBA, counts []int) []byte {
+	dir, err := ioutil.TempDir("", "pich8-capture")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "clip"+format.Extension())
+	r, err := Create(path, format)
+	assert.Nil(t, err)
+	for i, frame := range frames {
+		for n := 0; n < counts[i]; n++ {
+			assert.Nil(t, r.Frame(frame))
+		}
+	}
+	assert.Nil(t, r.Close())
+
+	data, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	return data
+}
+
+func TestFormatFromPath(t *testing.T) {
+	assert := assert.New(t)
+
+	for path, expected := range map[string]Format{"a.gif": GIF, "a.PNG": APNG, "a.apng": APNG, "dir/a.y4m": Y4M} {
+		format, err := FormatFromPath(path)
+		assert.Nil(err)
+		assert.Equal(expected, format)
+	}
+	_, err := FormatFromPath("a.mp4")
+	assert.NotNil(err)
+
+	for _, f := range Formats {
+		parsed, err := ParseFormat(f.String())
+		assert.Nil(err)
+		assert.Equal(f, parsed)
+	}
+}
+
+func TestGif(t *testing.T) {
+	assert := assert.New(t)
+
+	// Identical frames are merged, the second frame is shown for 1/100s only and dropped
+	frames := []*image.RGBA{newFrame(4, 2, black), newFrame(4, 2, white), newFrame(4, 2, black), newFrame(2, 1, white)}
+	data := record(t, GIF, frames, []int{1, 1, 58, 60})
+
+	g, err := gif.DecodeAll(bytes.NewReader(data))
+	assert.Nil(err)
+	assert.Equal(2, len(g.Image))
+	assert.Equal([]int{100, 100}, g.Delay)
+
+	// Frames of another size are scaled to the size of the first frame
+	assert.Equal(image.Rect(0, 0, 4, 2), g.Image[1].Rect)
+	r, _, _, _ := g.Image[1].At(3, 1).RGBA()
+	assert.Equal(uint32(0xffff), r)
+}
+
+func TestApng(t *testing.T) {
+	assert := assert.New(t)
+
+	frames := []*image.RGBA{newFrame(4, 2, black), newFrame(4, 2, white), newFrame(4, 2, black)}
+	data := record(t, APNG, frames, []int{30, 1, 29})
+
+	// Viewers without APNG support show the first frame
+	img, err := png.Decode(bytes.NewReader(data))
+	assert.Nil(err)
+	assert.Equal(image.Rect(0, 0, 4, 2), img.Bounds())
+
+	// Check the animation chunks
+	var types []string
+	var delays []uint16
+	var numFrames uint32
+	chunks := data[len(pngSignature):]
+	for len(chunks) >= 12 {
+		length := binary.BigEndian.Uint32(chunks)
+		typ := string(chunks[4:8])
+		content := chunks[8 : 8+length]
+		types = append(types, typ)
+		switch typ {
+		case "acTL":
+			numFrames = binary.BigEndian.Uint32(content)
+		case "fcTL":
+			delays = append(delays, binary.BigEndian.Uint16(content[20:]))
+			assert.Equal(uint16(FrameRate), binary.BigEndian.Uint16(content[22:]))
+		}
+		chunks = chunks[12+length:]
+	}
+	assert.Equal([]string{"IHDR", "acTL", "fcTL", "IDAT", "fcTL", "fdAT", "fcTL", "fdAT", "IEND"}, types)
+	assert.Equal(uint32(3), numFrames)
+	assert.Equal([]uint16{30, 1, 29}, delays)
+}
+
+func TestY4m(t *testing.T) {
+	assert := assert.New(t)
+
+	frames := []*image.RGBA{newFrame(4, 2, black), newFrame(4, 2, white)}
+	data := record(t, Y4M, frames, []int{2, 1})
+
+	header := "YUV4MPEG2 W4 H2 F60:1 Ip A1:1 C444 XCOLORRANGE=FULL\n"
+	frameSize := len("FRAME\n") + 3*4*2
+	assert.Equal(header, string(data[:len(header)]))
+	assert.Equal(len(header)+3*frameSize, len(data))
+
+	// Luma of the last frame is white, the chroma is neutral
+	last := data[len(data)-3*4*2:]
+	assert.Equal(byte(255), last[0])
+	assert.Equal(byte(128), last[8])
+}
+
+func TestEmptyRecording(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "pich8-capture")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	r, err := Create(filepath.Join(dir, "clip.gif"), GIF)
+	assert.Nil(err)
+	assert.NotNil(r.Close())
+}
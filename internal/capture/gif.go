@@ -0,0 +1,133 @@
+package capture
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"os"
+)
+
+// minGifDelay is the shortest frame duration in 1/100s which all viewers show as is
+const minGifDelay = 2
+
+// gifRecorder collects the frames and writes the GIF on closing, identical frames are merged
+type gifRecorder struct {
+	file   *os.File
+	canvas canvas
+	gif    gif.GIF
+	starts []int
+
+	// The frame which is shown until the next different frame
+	pending      *image.Paletted
+	pendingStart int
+	frames       int
+}
+
+func newGifRecorder(path string) (*gifRecorder, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &gifRecorder{file: file}, nil
+}
+
+// centiseconds returns the time of the given frame in 1/100s
+func centiseconds(frame int) int {
+	return (frame*100 + FrameRate/2) / FrameRate
+}
+
+func (r *gifRecorder) Frame(img *image.RGBA) error {
+	img, same := r.canvas.next(img)
+	r.frames++
+	if same {
+		return nil
+	}
+
+	frame := toPaletted(img)
+	if r.pending != nil && centiseconds(r.frames-1)-centiseconds(r.pendingStart) < minGifDelay {
+		// The pending frame is too short, it's replaced by the new one or dropped if the new one equals the previous frame
+		if last := len(r.gif.Image) - 1; last >= 0 && samePaletted(r.gif.Image[last], frame) {
+			r.pending, r.pendingStart = r.gif.Image[last], r.starts[last]
+			r.gif.Image, r.gif.Delay, r.starts = r.gif.Image[:last], r.gif.Delay[:last], r.starts[:last]
+		} else {
+			r.pending = frame
+		}
+		return nil
+	}
+	r.flush(r.frames - 1)
+	r.pending = frame
+	r.pendingStart = r.frames - 1
+	return nil
+}
+
+// flush adds the pending frame, which ends before the given frame
+func (r *gifRecorder) flush(end int) {
+	if r.pending == nil {
+		return
+	}
+	delay := centiseconds(end) - centiseconds(r.pendingStart)
+	if delay < minGifDelay {
+		delay = minGifDelay
+	}
+	r.gif.Image = append(r.gif.Image, r.pending)
+	r.gif.Delay = append(r.gif.Delay, delay)
+	r.starts = append(r.starts, r.pendingStart)
+	r.pending = nil
+}
+
+func (r *gifRecorder) Close() error {
+	defer r.file.Close()
+
+	r.flush(r.frames)
+	if len(r.gif.Image) == 0 {
+		return errors.New("no frames recorded")
+	}
+	if err := gif.EncodeAll(r.file, &r.gif); err != nil {
+		return err
+	}
+	return r.file.Close()
+}
+
+func samePaletted(a, b *image.Paletted) bool {
+	if len(a.Palette) != len(b.Palette) || !bytes.Equal(a.Pix, b.Pix) {
+		return false
+	}
+	for i := range a.Palette {
+		if a.Palette[i] != b.Palette[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// toPaletted converts the image to a paletted image with the colors of the image,
+// if there are too many colors the web-safe palette is used
+func toPaletted(img *image.RGBA) *image.Paletted {
+	var colors color.Palette
+	index := make(map[color.RGBA]uint8)
+	for i := 0; i < len(img.Pix); i += 4 {
+		c := color.RGBA{img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3]}
+		if _, ok := index[c]; ok {
+			continue
+		}
+		if len(colors) == 256 {
+			paletted := image.NewPaletted(img.Rect, palette.WebSafe)
+			draw.Draw(paletted, img.Rect, img, img.Rect.Min, draw.Src)
+			return paletted
+		}
+		index[c] = uint8(len(colors))
+		colors = append(colors, c)
+	}
+
+	paletted := image.NewPaletted(img.Rect, colors)
+	for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+		for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+			paletted.SetColorIndex(x, y, index[img.RGBAAt(x, y)])
+		}
+	}
+	return paletted
+}
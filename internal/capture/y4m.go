@@ -0,0 +1,60 @@
+package capture
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+)
+
+// y4mRecorder writes every frame to an uncompressed YUV4MPEG2 video with full chroma resolution
+type y4mRecorder struct {
+	file   *os.File
+	writer *bufio.Writer
+	canvas canvas
+	planes []byte
+}
+
+func newY4mRecorder(path string) (*y4mRecorder, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &y4mRecorder{file: file, writer: bufio.NewWriter(file)}, nil
+}
+
+func (r *y4mRecorder) Frame(img *image.RGBA) error {
+	first := r.canvas.cur == nil
+	img, _ = r.canvas.next(img)
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	if first {
+		// The colors are converted with the full range of image/color
+		if _, err := fmt.Fprintf(r.writer, "YUV4MPEG2 W%v H%v F%v:1 Ip A1:1 C444 XCOLORRANGE=FULL\n", w, h, FrameRate); err != nil {
+			return err
+		}
+		r.planes = make([]byte, 3*w*h)
+	}
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := img.RGBAAt(x, y)
+			i := y*w + x
+			r.planes[i], r.planes[w*h+i], r.planes[2*w*h+i] = color.RGBToYCbCr(c.R, c.G, c.B)
+		}
+	}
+	if _, err := r.writer.WriteString("FRAME\n"); err != nil {
+		return err
+	}
+	_, err := r.writer.Write(r.planes)
+	return err
+}
+
+func (r *y4mRecorder) Close() error {
+	defer r.file.Close()
+
+	if err := r.writer.Flush(); err != nil {
+		return err
+	}
+	return r.file.Close()
+}
@@ -0,0 +1,181 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/philw07/pich8-go/internal/capture"
+	"github.com/philw07/pich8-go/internal/cpu"
+	"github.com/philw07/pich8-go/internal/palette"
+	"github.com/philw07/pich8-go/internal/video"
+)
+
+// appName is the name of the directory in the user config directory
+const appName = "pich8-go"
+
+// Settings holds the settings which are persisted between launches
+type Settings struct {
+	// CPU speed in instructions per second
+	Speed      int             `json:"speed"`
+	Profile    string          `json:"profile"`
+	Quirks     cpu.Quirks      `json:"quirks"`
+	VSync      bool            `json:"vsync"`
+	Fullscreen bool            `json:"fullscreen"`
+	Window     Window          `json:"window"`
+	Palette    palette.Palette `json:"palette"`
+	Scaling    video.ScaleMode `json:"scaling"`
+	Border     palette.Color   `json:"border"`
+	Scaler     video.Scaler    `json:"scaler"`
+	Filters    video.Filters   `json:"filters"`
+	Volume     float64         `json:"volume"`
+	Muted      bool            `json:"muted"`
+
+	// Size of a CHIP-8 pixel in screenshots, 1 for the native resolution
+	ScreenshotScale int `json:"screenshotScale"`
+	// Format and scale of the video recordings
+	CaptureFormat capture.Format `json:"captureFormat"`
+	CaptureScale  int            `json:"captureScale"`
+
+	// Key bindings file, empty for bindings.json in the config directory
+	Bindings     string `json:"bindings,omitempty"`
+	RomDirectory string `json:"romDirectory,omitempty"`
+
+	// Directory of the ROM database, empty for chip-8-database in the config directory
+	Database string `json:"database,omitempty"`
+
+	// Directory of the screenshots and video recordings, empty for screenshots in the config directory
+	Screenshots string `json:"screenshots,omitempty"`
+}
+
+// Window is the position and size of the window in windowed mode, a zero size centers the default size
+type Window struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// Default returns the default settings
+func Default() *Settings {
+	return &Settings{
+		Speed:   720,
+		Profile: cpu.DefaultProfile,
+		Quirks:  cpu.Profiles[cpu.DefaultProfile],
+		Palette: palette.Default,
+		Scaling: video.Aspect,
+		Border:  palette.Color{A: 0xff},
+		Volume:  0.25,
+
+		ScreenshotScale: 1,
+		CaptureScale:    1,
+	}
+}
+
+// Dir returns the config directory of the emulator
+func Dir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "."
+	}
+	return filepath.Join(dir, appName)
+}
+
+// DefaultPath returns the path of the settings file in the config directory
+func DefaultPath() string {
+	return filepath.Join(Dir(), "settings.json")
+}
+
+// BindingsFile returns the path of the key bindings file
+func (s *Settings) BindingsFile() string {
+	if s.Bindings == "" {
+		return filepath.Join(Dir(), "bindings.json")
+	}
+	return s.Bindings
+}
+
+// DatabaseDir returns the directory of the ROM database
+func (s *Settings) DatabaseDir() string {
+	if s.Database == "" {
+		return filepath.Join(Dir(), "chip-8-database")
+	}
+	return s.Database
+}
+
+// ScreenshotDir returns the directory the screenshots are saved to
+func (s *Settings) ScreenshotDir() string {
+	if s.Screenshots == "" {
+		return filepath.Join(Dir(), "screenshots")
+	}
+	return s.Screenshots
+}
+
+// RomOverridesFile returns the path of the file with the local ROM database entries
+func RomOverridesFile() string {
+	return filepath.Join(Dir(), "roms.json")
+}
+
+// PalettesFile returns the path of the file with the user palettes
+func PalettesFile() string {
+	return filepath.Join(Dir(), "palettes.json")
+}
+
+// Load loads the settings from the given file, missing values are taken from the defaults.
+// If the file doesn't exist, the default settings are returned.
+func Load(path string) (*Settings, error) {
+	s := Default()
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	// The quirks of the profile apply unless they're listed explicitly
+	var file struct {
+		Profile string          `json:"profile"`
+		Quirks  json.RawMessage `json:"quirks"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid settings file %v: %v", path, err)
+	}
+	if file.Profile != "" && file.Profile != "custom" && file.Quirks == nil {
+		if s.Quirks, err = cpu.ProfileQuirks(file.Profile); err != nil {
+			return nil, fmt.Errorf("invalid settings file %v: %v", path, err)
+		}
+	}
+
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("invalid settings file %v: %v", path, err)
+	}
+	if s.Speed <= 0 {
+		return nil, fmt.Errorf("invalid speed %v in %v", s.Speed, path)
+	}
+	if s.ScreenshotScale < 1 {
+		return nil, fmt.Errorf("invalid screenshot scale %v in %v", s.ScreenshotScale, path)
+	}
+	if s.CaptureScale < 1 {
+		return nil, fmt.Errorf("invalid capture scale %v in %v", s.CaptureScale, path)
+	}
+	if err := s.Filters.Validate(); err != nil {
+		return nil, fmt.Errorf("%v in %v", err, path)
+	}
+	s.Profile = cpu.ProfileName(s.Quirks)
+
+	return s, nil
+}
+
+// Save writes the settings to the given file, the directory is created if needed
+func (s *Settings) Save(path string) error {
+	s.Profile = cpu.ProfileName(s.Quirks)
+	data, err := json.MarshalIndent(s, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/philw07/pich8-go/internal/capture"
+	"github.com/philw07/pich8-go/internal/cpu"
+	"github.com/philw07/pich8-go/internal/palette"
+	"github.com/philw07/pich8-go/internal/video"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadSave(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "pich8-go")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "sub", "settings.json")
+
+	// Missing file
+	s, err := Load(path)
+	assert.Nil(err)
+	assert.Equal(Default(), s)
+	assert.Equal(filepath.Join(Dir(), "bindings.json"), s.BindingsFile())
+	assert.Equal(filepath.Join(Dir(), "chip-8-database"), s.DatabaseDir())
+	assert.Equal(filepath.Join(Dir(), "screenshots"), s.ScreenshotDir())
+
+	// Round trip
+	s.Speed = 1200
+	s.Quirks.Shift = false
+	s.Fullscreen = true
+	s.Window = Window{X: 10, Y: 20, Width: 800, Height: 400}
+	s.Palette[1] = palette.Color{R: 0xff, G: 0x80, B: 0x00, A: 0xff}
+	s.Scaling = video.Integer
+	s.Border = palette.Color{R: 0x20, G: 0x20, B: 0x20, A: 0xff}
+	s.Scaler = video.HQ2x
+	s.Filters = video.Filters{Persistence: 0.5, Grid: 0.2}
+	s.ScreenshotScale = 4
+	s.CaptureFormat = capture.APNG
+	s.CaptureScale = 2
+	s.Screenshots = "/shots"
+	s.Bindings = "keys.json"
+	s.RomDirectory = "/roms"
+	assert.Nil(s.Save(path))
+	loaded, err := Load(path)
+	assert.Nil(err)
+	assert.Equal(s, loaded)
+	assert.Equal("custom", loaded.Profile)
+	assert.Equal("keys.json", loaded.BindingsFile())
+
+	// Profile without quirks, missing values are defaults
+	ioutil.WriteFile(path, []byte(`{"profile": "chip8", "vsync": true}`), 0644)
+	s, err = Load(path)
+	assert.Nil(err)
+	assert.Equal(cpu.Profiles["chip8"], s.Quirks)
+	assert.Equal("chip8", s.Profile)
+	assert.True(s.VSync)
+	assert.Equal(720, s.Speed)
+	assert.Equal(palette.Default, s.Palette)
+
+	// Errors
+	ioutil.WriteFile(path, []byte(`{"profile": "unknown"}`), 0644)
+	_, err = Load(path)
+	assert.NotNil(err)
+	ioutil.WriteFile(path, []byte(`{"scaling": "zoom"}`), 0644)
+	_, err = Load(path)
+	assert.NotNil(err)
+	ioutil.WriteFile(path, []byte(`{"filters": {"scanlines": 2}}`), 0644)
+	_, err = Load(path)
+	assert.NotNil(err)
+	ioutil.WriteFile(path, []byte(`{"screenshotScale": 0}`), 0644)
+	_, err = Load(path)
+	assert.NotNil(err)
+	ioutil.WriteFile(path, []byte(`{"captureFormat": "mp4"}`), 0644)
+	_, err = Load(path)
+	assert.NotNil(err)
+	ioutil.WriteFile(path, []byte(`{"speed": 0}`), 0644)
+	_, err = Load(path)
+	assert.NotNil(err)
+	ioutil.WriteFile(path, []byte(`{"palette": ["#000000", "white", "#000000", "#000000"]}`), 0644)
+	_, err = Load(path)
+	assert.NotNil(err)
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// FlagsFile returns the path of the file holding the flag registers of the ROM with the given hash
+func FlagsFile(romHash string) string {
+	return filepath.Join(Dir(), "flags", romHash+".flags")
+}
+
+// LoadFlags loads the flag registers from the given file, a missing file results in cleared flags.
+// Files with 8 flags as written for SCHIP ROMs are accepted as well.
+func LoadFlags(path string) ([16]byte, error) {
+	var flags [16]byte
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return flags, nil
+	} else if err != nil {
+		return flags, err
+	}
+	if len(data) != 8 && len(data) != len(flags) {
+		return flags, fmt.Errorf("invalid flags file %v", path)
+	}
+	copy(flags[:], data)
+	return flags, nil
+}
+
+// SaveFlags writes the flag registers to the given file, the directory is created if needed
+func SaveFlags(path string, flags [16]byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, flags[:], 0644)
+}
+
+// ClearFlags removes the given flags file
+func ClearFlags(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlags(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "pich8-go")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "flags", "rom.flags")
+
+	// Missing file
+	flags, err := LoadFlags(path)
+	assert.Nil(err)
+	assert.Equal([16]byte{}, flags)
+
+	flags[0] = 0x12
+	flags[15] = 0x34
+	assert.Nil(SaveFlags(path, flags))
+	loaded, err := LoadFlags(path)
+	assert.Nil(err)
+	assert.Equal(flags, loaded)
+
+	// SCHIP flags
+	ioutil.WriteFile(path, []byte{1, 2, 3, 4, 5, 6, 7, 8}, 0644)
+	loaded, err = LoadFlags(path)
+	assert.Nil(err)
+	assert.Equal([16]byte{1, 2, 3, 4, 5, 6, 7, 8}, loaded)
+
+	ioutil.WriteFile(path, []byte{1, 2, 3}, 0644)
+	_, err = LoadFlags(path)
+	assert.NotNil(err)
+
+	assert.Nil(ClearFlags(path))
+	assert.Nil(ClearFlags(path))
+	loaded, err = LoadFlags(path)
+	assert.Nil(err)
+	assert.Equal([16]byte{}, loaded)
+}
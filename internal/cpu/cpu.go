@@ -2,6 +2,8 @@ package cpu
 
 import (
 	"errors"
+	"math/rand"
+	"time"
 
 	"github.com/philw07/pich8-go/internal/videomemory"
 )
@@ -39,27 +41,37 @@ var fontsetBig = [...]byte{
 	0x3C, 0x7E, 0xC3, 0xC3, 0x7F, 0x3F, 0x03, 0x03, 0x3E, 0x7C, // 9
 }
 
+// latchTicks is the number of timer ticks a tap is latched after the key is released
+const latchTicks = 2
+
 // CPU implements the CHIP-8 CPU
 type CPU struct {
 	mem         [65536]byte
 	vmem        videomemory.VideoMemory
 	stack       [16]uint16
 	keys        [16]bool
-	audioBuffer *[16]byte
+	keysLatched [16]byte
+	keysTested  [16]bool
+	audioBuffer [16]byte
+	hasAudio    bool
+	rng         *rand.Rand
+	random      *randomSource
 
 	PC  uint16
 	V   [16]byte
 	I   uint16
 	DT  byte
 	ST  byte
-	RPL [8]byte
+	RPL [16]byte
 
 	opcode uint16
 	sp     byte
 
-	draw    bool
-	keyWait bool
-	keyReg  byte
+	draw       bool
+	stWritten  bool
+	keyWait    bool
+	keyReg     byte
+	keyWaitKey int
 
 	QuirkLoadStore    bool
 	QuirkShift        bool
@@ -72,10 +84,14 @@ type CPU struct {
 
 // NewCPU creates a new CPU instance
 func NewCPU() *CPU {
+	random := newRandomSource(time.Now().UnixNano())
 	cpu := CPU{
 		vmem:           *videomemory.NewVideoMemory(),
 		PC:             initialPC,
 		draw:           true,
+		keyWaitKey:     -1,
+		rng:            rand.New(random),
+		random:         random,
 		QuirkLoadStore: true,
 		QuirkShift:     true,
 		QuirkJump:      true,
@@ -97,7 +113,25 @@ func (cpu *CPU) Vmem() videomemory.VideoMemory {
 
 // AudioBuffer returns the audio buffer if available or otherwise nil
 func (cpu *CPU) AudioBuffer() *[16]byte {
-	return cpu.audioBuffer
+	if !cpu.hasAudio {
+		return nil
+	}
+	return &cpu.audioBuffer
+}
+
+// Seed initializes the random number generator to a deterministic state
+func (cpu *CPU) Seed(seed int64) {
+	cpu.rng.Seed(seed)
+}
+
+// NextOpcode returns the opcode which will be executed by the next cycle
+func (cpu *CPU) NextOpcode() uint16 {
+	return uint16(cpu.mem[cpu.PC])<<8 | uint16(cpu.mem[cpu.PC+1])
+}
+
+// WaitingForKey returns whether the CPU is halted until a key is pressed
+func (cpu *CPU) WaitingForKey() bool {
+	return cpu.keyWait
 }
 
 // LoadRom loads the given ROM into the memory
@@ -112,6 +146,14 @@ func (cpu *CPU) LoadRom(prog []byte) error {
 	return errors.New("invalid ROM")
 }
 
+// SoundTimerWritten returns whether the sound timer was set since the last call,
+// also if it was set to the value it already had
+func (cpu *CPU) SoundTimerWritten() bool {
+	written := cpu.stWritten
+	cpu.stWritten = false
+	return written
+}
+
 // UpdateTimers decreases the delay and sound timers
 func (cpu *CPU) UpdateTimers() {
 	if cpu.DT > 0 {
@@ -120,20 +162,50 @@ func (cpu *CPU) UpdateTimers() {
 	if cpu.ST > 0 {
 		cpu.ST--
 	}
+
+	// Latched taps expire if the ROM doesn't test the key
+	for key, latched := range cpu.keysLatched {
+		if latched > 0 && !cpu.keys[key] {
+			cpu.keysLatched[key]--
+		}
+	}
 }
 
-// Tick performs one CPU cycle
+// SetKey updates the state of a key.
+// A press is latched until the key is tested, so that short taps aren't lost between two instructions.
+// The latch of a released key expires at the second timer tick, so it lasts at least one frame.
+func (cpu *CPU) SetKey(key byte, pressed bool) {
+	key &= 0xF
+	if pressed && !cpu.keys[key] {
+		cpu.keysLatched[key] = latchTicks
+		if cpu.keyWait && cpu.keyWaitKey < 0 {
+			cpu.keyWaitKey = int(key)
+		}
+	} else if !pressed && cpu.keys[key] {
+		// Waiting for a key completes when the pressed key is released
+		if cpu.keyWait && cpu.keyWaitKey == int(key) {
+			cpu.keysTested[key] = true
+			cpu.V[cpu.keyReg] = key
+			cpu.keyWait = false
+			cpu.keyWaitKey = -1
+		}
+	}
+	cpu.keys[key] = pressed
+}
+
+// Tick updates the key states and performs one CPU cycle
 func (cpu *CPU) Tick(keys [16]bool) error {
-	copy(cpu.keys[:], keys[:])
-	if cpu.keyWait {
-		for i, pressed := range keys {
-			if pressed {
-				cpu.keyWait = false
-				cpu.V[cpu.keyReg] = byte(i)
-			}
+	for i, pressed := range keys {
+		if pressed != cpu.keys[i] {
+			cpu.SetKey(byte(i), pressed)
 		}
 	}
 
+	return cpu.Step()
+}
+
+// Step performs one CPU cycle with the current key states
+func (cpu *CPU) Step() error {
 	if cpu.keyWait {
 		return nil
 	}
@@ -141,6 +213,40 @@ func (cpu *CPU) Tick(keys [16]bool) error {
 	return cpu.emulateCycle()
 }
 
+// readMem fills the buffer from the memory at the given address, the address wraps around at the end of the memory
+func (cpu *CPU) readMem(buf []byte, addr uint16) {
+	for k := range buf {
+		buf[k] = cpu.mem[addr+uint16(k)]
+	}
+}
+
+// writeMem writes the data to the memory at the given address, the address wraps around at the end of the memory
+func (cpu *CPU) writeMem(addr uint16, data []byte) {
+	for k, b := range data {
+		cpu.mem[addr+uint16(k)] = b
+	}
+}
+
+// keyPressed returns whether a key is pressed or has been pressed since it was last tested
+func (cpu *CPU) keyPressed(key byte) bool {
+	key &= 0xF
+	pressed := cpu.keys[key] || cpu.keysLatched[key] > 0
+	cpu.keysLatched[key] = 0
+	cpu.keysTested[key] = true
+	return pressed
+}
+
+// TestedKeys returns the keys which have been tested by the ROM so far,
+// either by 0xEX9E/0xEXA1 or by completing 0xFX0A
+func (cpu *CPU) TestedKeys() [16]bool {
+	return cpu.keysTested
+}
+
+// PressedKeys returns the current key states
+func (cpu *CPU) PressedKeys() [16]bool {
+	return cpu.keys
+}
+
 func (cpu *CPU) emulateCycle() error {
 	// Fetch opcode
 	cpu.opcode = uint16(cpu.mem[cpu.PC])<<8 | uint16(cpu.mem[cpu.PC+1])
@@ -312,13 +418,15 @@ func (cpu *CPU) drawSprite(x, y byte, height byte) {
 	}
 
 	collision := false
-	i := int(cpu.I)
+	i := cpu.I
 	length := width / 8 * int(height)
+	var buf [32]byte
 
 	for _, plane := range [...]videomemory.Plane{videomemory.FirstPlane, videomemory.SecondPlane} {
 		if cpu.vmem.Plane == plane || cpu.vmem.Plane == videomemory.BothPlanes {
-			sprite := cpu.mem[i : i+length]
-			i += length
+			sprite := buf[:length]
+			cpu.readMem(sprite, i)
+			i += uint16(length)
 
 			for k := 0; k < len(sprite); k += step {
 				curY := int(y) + (k / step)
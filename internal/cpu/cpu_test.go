@@ -346,10 +346,16 @@ func TestOpcodes(t *testing.T) {
 
 	// 0xFX18
 	cpu = NewCPU()
-	cpu.LoadRom([]byte{0xF0, 0x18})
+	cpu.LoadRom([]byte{0xF0, 0x18, 0xF0, 0x18})
 	cpu.V[0] = 0x15
+	assert.False(cpu.SoundTimerWritten())
 	cpu.emulateCycle()
 	assert.EqualValues(0x15, cpu.ST)
+	assert.True(cpu.SoundTimerWritten())
+	assert.False(cpu.SoundTimerWritten())
+	// Writing the same value again counts as well
+	cpu.emulateCycle()
+	assert.True(cpu.SoundTimerWritten())
 
 	// 0xFX1E
 	cpu = NewCPU()
@@ -497,6 +503,17 @@ func TestOpcodesSChip(t *testing.T) {
 	assert.EqualValues(cpu.RPL[:5], cpu.V[:5])
 	assert.Zero(cpu.V[5])
 	assert.EqualValues(0x202, cpu.PC)
+
+	// 0xFX75 / 0xFX85 - XO-CHIP - 16 flags
+	cpu = NewCPU()
+	cpu.LoadRom([]byte{0xFF, 0x75, 0x6F, 0x00, 0xFF, 0x85})
+	copy(cpu.V[:], reg)
+	cpu.V[0xF] = 0x42
+	cpu.emulateCycle()
+	assert.EqualValues(0x42, cpu.RPL[0xF])
+	cpu.emulateCycle()
+	cpu.emulateCycle()
+	assert.EqualValues(0x42, cpu.V[0xF])
 }
 
 func TestOpcodesXOChip(t *testing.T) {
@@ -632,6 +649,161 @@ func TestOpcodesArithmetic(t *testing.T) {
 	testArithmeticVNoQuirk(assert, 0x801E, b2, b1, b1<<1, 0)
 }
 
+func TestKeys(t *testing.T) {
+	assert := assert.New(t)
+
+	// Short tap is latched until it's tested
+	cpu := NewCPU()
+	cpu.LoadRom([]byte{0xE0, 0x9E, 0x00, 0x00, 0xE0, 0x9E})
+	cpu.V[0] = 3
+	cpu.SetKey(3, true)
+	cpu.SetKey(3, false)
+	cpu.Step()
+	assert.EqualValues(0x204, cpu.PC)
+	cpu.Step()
+	assert.EqualValues(0x206, cpu.PC)
+
+	// An untested tap expires within two timer ticks of its release, a held key stays pressed
+	cpu = NewCPU()
+	cpu.LoadRom([]byte{0xE0, 0x9E, 0x00, 0x00, 0xE0, 0x9E})
+	cpu.V[0] = 3
+	cpu.SetKey(3, true)
+	cpu.UpdateTimers()
+	cpu.UpdateTimers()
+	cpu.SetKey(3, false)
+	cpu.UpdateTimers()
+	assert.Equal(byte(1), cpu.keysLatched[3])
+	cpu.UpdateTimers()
+	cpu.Step()
+	assert.EqualValues(0x202, cpu.PC)
+
+	// Tap is latched for 0xEXA1 as well
+	cpu = NewCPU()
+	cpu.LoadRom([]byte{0xE0, 0xA1})
+	cpu.V[0] = 3
+	cpu.SetKey(3, true)
+	cpu.SetKey(3, false)
+	cpu.Step()
+	assert.EqualValues(0x202, cpu.PC)
+
+	// 0xFX0A completes on release of a key pressed while waiting
+	cpu = NewCPU()
+	cpu.LoadRom([]byte{0xF5, 0x0A, 0x00, 0x00})
+	cpu.SetKey(7, true)
+	cpu.Step()
+	assert.True(cpu.WaitingForKey())
+	cpu.SetKey(7, false)
+	assert.True(cpu.WaitingForKey())
+	cpu.SetKey(0xB, true)
+	cpu.SetKey(2, true)
+	cpu.SetKey(2, false)
+	assert.True(cpu.WaitingForKey())
+	cpu.Step()
+	assert.EqualValues(0x202, cpu.PC)
+	cpu.SetKey(0xB, false)
+	assert.False(cpu.WaitingForKey())
+	assert.EqualValues(0xB, cpu.V[5])
+	cpu.Step()
+	assert.EqualValues(0x204, cpu.PC)
+
+	// Tested keys
+	cpu = NewCPU()
+	cpu.LoadRom([]byte{0xE0, 0x9E, 0xE1, 0x9E, 0xF2, 0x0A})
+	cpu.V[0] = 3
+	cpu.V[1] = 0xC
+	cpu.Step()
+	cpu.Step()
+	cpu.Step()
+	cpu.SetKey(9, true)
+	cpu.SetKey(9, false)
+	assert.Equal([16]bool{3: true, 9: true, 0xC: true}, cpu.TestedKeys())
+
+	// Tick applies the key states
+	cpu = NewCPU()
+	cpu.LoadRom([]byte{0xF5, 0x0A, 0x00, 0x00})
+	cpu.Tick([16]bool{})
+	cpu.Tick([16]bool{1: true})
+	assert.True(cpu.WaitingForKey())
+	cpu.Tick([16]bool{})
+	assert.False(cpu.WaitingForKey())
+	assert.EqualValues(1, cpu.V[5])
+	assert.EqualValues(0x204, cpu.PC)
+}
+
+func TestQuirks(t *testing.T) {
+	assert := assert.New(t)
+
+	cpu := NewCPU()
+	assert.Equal(Profiles[DefaultProfile], cpu.Quirks())
+	assert.Equal(DefaultProfile, ProfileName(cpu.Quirks()))
+
+	quirks, err := ProfileQuirks("chip8")
+	assert.Nil(err)
+	cpu.SetQuirks(quirks)
+	assert.False(cpu.QuirkLoadStore)
+	assert.False(cpu.QuirkShift)
+	assert.Equal(quirks, cpu.Quirks())
+	assert.Equal("chip8", ProfileName(cpu.Quirks()))
+
+	cpu.QuirkDraw = true
+	cpu.QuirkShift = true
+	assert.Equal("custom", ProfileName(cpu.Quirks()))
+
+	_, err = ProfileQuirks("unknown")
+	assert.NotNil(err)
+}
+
+func TestSeed(t *testing.T) {
+	assert := assert.New(t)
+
+	rom := []byte{0xC0, 0xFF, 0xC1, 0xFF, 0xC2, 0xFF}
+	cpu1 := NewCPU()
+	cpu1.LoadRom(rom)
+	cpu1.Seed(1234)
+	cpu2 := NewCPU()
+	cpu2.LoadRom(rom)
+	cpu2.Seed(1234)
+	for i := 0; i < 3; i++ {
+		cpu1.Step()
+		cpu2.Step()
+	}
+	assert.Equal(cpu1.V, cpu2.V)
+}
+
+func TestState(t *testing.T) {
+	assert := assert.New(t)
+
+	// Random numbers drawn to the screen
+	rom := []byte{0xC0, 0x3F, 0xC1, 0x1F, 0xA0, 0x00, 0xD0, 0x15, 0x12, 0x00}
+	cpu1 := NewCPU()
+	cpu1.LoadRom(rom)
+	cpu1.SetQuirks(Profiles["chip8"])
+	for i := 0; i < 100; i++ {
+		cpu1.Step()
+	}
+	state, err := cpu1.MarshalBinary()
+	assert.Nil(err)
+
+	cpu2 := NewCPU()
+	assert.Nil(cpu2.UnmarshalBinary(state))
+	assert.Equal(cpu1.Quirks(), cpu2.Quirks())
+	for i := 0; i < 100; i++ {
+		cpu1.Step()
+		cpu2.Step()
+	}
+	assert.Equal(cpu1.V, cpu2.V)
+	assert.Equal(cpu1.PC, cpu2.PC)
+	assert.Equal(cpu1.Vmem(), cpu2.Vmem())
+
+	assert.NotNil(cpu2.UnmarshalBinary(state[:100]))
+	// The random number generator is restored directly, invalid positions are rejected
+	offset := 4 + 2 + 65536 + 2*16 + 1 + 3*16 + 16 + 1
+	state[offset+1] = 0xFF
+	assert.NotNil(cpu2.UnmarshalBinary(state))
+	state[0] = 'X'
+	assert.NotNil(cpu2.UnmarshalBinary(state))
+}
+
 func testArithmeticV(assert *assert.Assertions, opcode uint16, v1, v2, res, resv byte) {
 	cpu := NewCPU()
 	cpu.LoadRom([]byte{byte(opcode >> 8), byte(opcode)})
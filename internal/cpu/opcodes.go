@@ -2,7 +2,6 @@ package cpu
 
 import (
 	"errors"
-	"math/rand"
 
 	"github.com/philw07/pich8-go/internal/videomemory"
 )
@@ -146,7 +145,7 @@ func (cpu *CPU) opcodeXOChip0x5XY2(x, y byte) {
 		first = y
 		last = x
 	}
-	copy(cpu.mem[cpu.I:cpu.I+uint16(last)-uint16(first)+1], cpu.V[first:last+1])
+	cpu.writeMem(cpu.I, cpu.V[first:last+1])
 	cpu.PC += 2
 }
 
@@ -158,7 +157,7 @@ func (cpu *CPU) opcodeXOChip0x5XY3(x, y byte) {
 		first = y
 		last = x
 	}
-	copy(cpu.V[first:last+1], cpu.mem[cpu.I:cpu.I+uint16(last)-uint16(first)+1])
+	cpu.readMem(cpu.V[first:last+1], cpu.I)
 	cpu.PC += 2
 }
 
@@ -284,7 +283,7 @@ func (cpu *CPU) opcode0xBNNN(nnn uint16) {
 
 // 0xCXNN - Vx = rand() & nn
 func (cpu *CPU) opcode0xCXNN(x, nn byte) {
-	cpu.V[x] = byte(rand.Uint32()) & nn
+	cpu.V[x] = byte(cpu.rng.Uint32()) & nn
 	cpu.PC += 2
 }
 
@@ -297,7 +296,7 @@ func (cpu *CPU) opcode0xDXYN(x, y, n byte) {
 
 // 0xEX9E - Skip next instruction if key(Vx) is pressed
 func (cpu *CPU) opcode0xEX9E(x byte) {
-	if cpu.keys[cpu.V[x]] {
+	if cpu.keyPressed(cpu.V[x]) {
 		cpu.skipNextInstruction()
 	}
 	cpu.PC += 2
@@ -305,7 +304,7 @@ func (cpu *CPU) opcode0xEX9E(x byte) {
 
 // 0xEXA1 - Skip next instruction if key(Vx) is not pressed
 func (cpu *CPU) opcode0xEXA1(x byte) {
-	if !cpu.keys[cpu.V[x]] {
+	if !cpu.keyPressed(cpu.V[x]) {
 		cpu.skipNextInstruction()
 	}
 	cpu.PC += 2
@@ -325,8 +324,8 @@ func (cpu *CPU) opcodeXOChip0xFN01(n byte) {
 
 // 0xF002 - XO-CHIP - Audio
 func (cpu *CPU) opcodeXOChip0xF002() {
-	cpu.audioBuffer = &[16]byte{}
-	copy(cpu.audioBuffer[:], cpu.mem[cpu.I:cpu.I+16])
+	cpu.readMem(cpu.audioBuffer[:], cpu.I)
+	cpu.hasAudio = true
 	cpu.PC += 2
 }
 
@@ -337,9 +336,11 @@ func (cpu *CPU) opcode0xFX07(x byte) {
 }
 
 // 0xFX0A - Vx = get_key();
+// Like on the COSMAC VIP, the key is stored when it's released
 func (cpu *CPU) opcode0xFX0A(x byte) {
 	cpu.keyWait = true
 	cpu.keyReg = x
+	cpu.keyWaitKey = -1
 	cpu.PC += 2
 }
 
@@ -352,6 +353,7 @@ func (cpu *CPU) opcode0xFX15(x byte) {
 // 0xFX18 - ST = Vx
 func (cpu *CPU) opcode0xFX18(x byte) {
 	cpu.ST = cpu.V[x]
+	cpu.stWritten = true
 	cpu.PC += 2
 }
 
@@ -378,9 +380,7 @@ func (cpu *CPU) opcode0xFX33(x byte) {
 	hundreds := cpu.V[x] / 100
 	tens := (cpu.V[x] % 100) / 10
 	ones := cpu.V[x] % 10
-	cpu.mem[cpu.I] = hundreds
-	cpu.mem[cpu.I+1] = tens
-	cpu.mem[cpu.I+2] = ones
+	cpu.writeMem(cpu.I, []byte{hundreds, tens, ones})
 	cpu.PC += 2
 }
 
@@ -388,9 +388,7 @@ func (cpu *CPU) opcode0xFX33(x byte) {
 // Original: I is incremented
 // Quirk:    I is not incremented
 func (cpu *CPU) opcode0xFX55(x byte) {
-	start := cpu.I
-	end := cpu.I + uint16(x)
-	copy(cpu.mem[start:end+1], cpu.V[:x+1])
+	cpu.writeMem(cpu.I, cpu.V[:x+1])
 	if !cpu.QuirkLoadStore {
 		cpu.I += uint16(x) + 1
 	}
@@ -401,22 +399,20 @@ func (cpu *CPU) opcode0xFX55(x byte) {
 // Original: I is incremented
 // Quirk:    I is not incremented
 func (cpu *CPU) opcode0xFX65(x byte) {
-	start := cpu.I
-	end := cpu.I + uint16(x)
-	copy(cpu.V[:x+1], cpu.mem[start:end+1])
+	cpu.readMem(cpu.V[:x+1], cpu.I)
 	if !cpu.QuirkLoadStore {
 		cpu.I += uint16(x) + 1
 	}
 	cpu.PC += 2
 }
 
-// 0xFX75 - SCHIP - Store V0..VX in RPL user flags (X < 8)
+// 0xFX75 - SCHIP - Store V0..VX in RPL user flags (X < 8 on SCHIP, X < 16 on XO-CHIP)
 func (cpu *CPU) opcodeSChip0xFX75(x byte) {
 	copy(cpu.RPL[:x+1], cpu.V[:x+1])
 	cpu.PC += 2
 }
 
-// 0xFX85 - SCHIP - Read V0..VX from RPL user flags (X < 8)
+// 0xFX85 - SCHIP - Read V0..VX from RPL user flags (X < 8 on SCHIP, X < 16 on XO-CHIP)
 func (cpu *CPU) opcodeSChip0xFX85(x byte) {
 	copy(cpu.V[:x+1], cpu.RPL[:x+1])
 	cpu.PC += 2
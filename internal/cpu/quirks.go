@@ -0,0 +1,65 @@
+package cpu
+
+import "fmt"
+
+// Quirks holds the configurable behaviors which differ between the CHIP-8 platforms
+type Quirks struct {
+	LoadStore bool `json:"loadStore"`
+	Shift     bool `json:"shift"`
+	Jump      bool `json:"jump"`
+	VfOrder   bool `json:"vfOrder"`
+	Draw      bool `json:"draw"`
+}
+
+// DefaultProfile is the name of the profile used by a new CPU
+const DefaultProfile = "pich8"
+
+// Profiles contains the quirks of the supported platforms
+var Profiles = map[string]Quirks{
+	DefaultProfile: {LoadStore: true, Shift: true, Jump: true, VfOrder: true, Draw: true},
+	"chip8":        {LoadStore: false, Shift: false, Jump: false, VfOrder: true, Draw: false},
+	"schip":        {LoadStore: true, Shift: true, Jump: true, VfOrder: true, Draw: true},
+	"xochip":       {LoadStore: false, Shift: false, Jump: false, VfOrder: true, Draw: true},
+}
+
+// ProfileNames contains the names of the profiles in a fixed order
+var ProfileNames = [...]string{DefaultProfile, "chip8", "schip", "xochip"}
+
+// ProfileQuirks returns the quirks of the profile with the given name
+func ProfileQuirks(name string) (Quirks, error) {
+	quirks, ok := Profiles[name]
+	if !ok {
+		return Quirks{}, fmt.Errorf("unknown platform profile %q", name)
+	}
+	return quirks, nil
+}
+
+// ProfileName returns the name of the first profile matching the given quirks or "custom"
+func ProfileName(quirks Quirks) string {
+	for _, name := range ProfileNames {
+		if Profiles[name] == quirks {
+			return name
+		}
+	}
+	return "custom"
+}
+
+// Quirks returns the active quirks
+func (cpu *CPU) Quirks() Quirks {
+	return Quirks{
+		LoadStore: cpu.QuirkLoadStore,
+		Shift:     cpu.QuirkShift,
+		Jump:      cpu.QuirkJump,
+		VfOrder:   cpu.QuirkVfOrder,
+		Draw:      cpu.QuirkDraw,
+	}
+}
+
+// SetQuirks sets the active quirks
+func (cpu *CPU) SetQuirks(quirks Quirks) {
+	cpu.QuirkLoadStore = quirks.LoadStore
+	cpu.QuirkShift = quirks.Shift
+	cpu.QuirkJump = quirks.Jump
+	cpu.QuirkVfOrder = quirks.VfOrder
+	cpu.QuirkDraw = quirks.Draw
+}
@@ -0,0 +1,60 @@
+package cpu
+
+import "math/rand"
+
+const (
+	randomLen  = 607
+	randomTap  = 273
+	randomMask = 1<<63 - 1
+)
+
+// randomSource is the additive lagged Fibonacci generator of math/rand, reimplemented so its state can be encoded.
+// Seeded with the same value, it generates exactly the same values as rand.NewSource, so recorded movies stay valid.
+type randomSource struct {
+	tap  int
+	feed int
+	vec  [randomLen]int64
+}
+
+func newRandomSource(seed int64) *randomSource {
+	s := &randomSource{}
+	s.Seed(seed)
+	return s
+}
+
+// Seed initializes the state like the source of math/rand does, without its table of precomputed values.
+// Every generated value replaces one value of the state, so after a full cycle the state consists of the generated values.
+// The seeded state is restored from there by stepping backwards.
+func (s *randomSource) Seed(seed int64) {
+	src := rand.NewSource(seed).(rand.Source64)
+	s.tap, s.feed = 0, randomLen-randomTap
+	for i := 0; i < randomLen; i++ {
+		s.feed = (s.feed + randomLen - 1) % randomLen
+		s.vec[s.feed] = int64(src.Uint64())
+	}
+
+	// The positions are back at the start after a full cycle
+	for i := 0; i < randomLen; i++ {
+		s.vec[s.feed] -= s.vec[s.tap]
+		s.tap = (s.tap + 1) % randomLen
+		s.feed = (s.feed + 1) % randomLen
+	}
+}
+
+func (s *randomSource) Uint64() uint64 {
+	s.tap--
+	if s.tap < 0 {
+		s.tap += randomLen
+	}
+	s.feed--
+	if s.feed < 0 {
+		s.feed += randomLen
+	}
+	x := s.vec[s.feed] + s.vec[s.tap]
+	s.vec[s.feed] = x
+	return uint64(x)
+}
+
+func (s *randomSource) Int63() int64 {
+	return int64(s.Uint64() & randomMask)
+}
@@ -0,0 +1,26 @@
+package cpu
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRandomSource(t *testing.T) {
+	assert := assert.New(t)
+
+	// The values match math/rand, also beyond the first cycle and after seeding again
+	src := newRandomSource(0)
+	for _, seed := range []int64{0, 1, -5, 89482311, 1 << 40} {
+		src.Seed(seed)
+		expected := rand.NewSource(seed).(rand.Source64)
+		for i := 0; i < 3*randomLen; i++ {
+			if i%2 == 0 {
+				assert.Equal(expected.Uint64(), src.Uint64())
+			} else {
+				assert.Equal(expected.Int63(), src.Int63())
+			}
+		}
+	}
+}
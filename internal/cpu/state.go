@@ -0,0 +1,132 @@
+package cpu
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+// stateVersion is increased whenever the layout of the encoded state changes
+const stateVersion = 1
+
+var stateMagic = [4]byte{'C', '8', 'S', 'T'}
+
+// cpuState is the encoded layout of the CPU state, the video memory follows it
+type cpuState struct {
+	Magic       [4]byte
+	Version     uint16
+	Mem         [65536]byte
+	Stack       [16]uint16
+	SP          byte
+	Keys        [16]bool
+	KeysLatched [16]byte
+	KeysTested  [16]bool
+	AudioBuffer [16]byte
+	HasAudio    bool
+	RandomTap   uint16
+	RandomFeed  uint16
+	Random      [randomLen]int64
+
+	PC  uint16
+	V   [16]byte
+	I   uint16
+	DT  byte
+	ST  byte
+	RPL [16]byte
+
+	Opcode     uint16
+	Draw       bool
+	KeyWait    bool
+	KeyReg     byte
+	KeyWaitKey int8
+	Quirks     [7]bool
+}
+
+// MarshalBinary encodes the complete state of the CPU including the memory, the video memory and the random number generator,
+// a CPU restored from it continues exactly like the encoded one
+func (cpu *CPU) MarshalBinary() ([]byte, error) {
+	state := cpuState{
+		Magic:       stateMagic,
+		Version:     stateVersion,
+		Mem:         cpu.mem,
+		Stack:       cpu.stack,
+		SP:          cpu.sp,
+		Keys:        cpu.keys,
+		KeysLatched: cpu.keysLatched,
+		KeysTested:  cpu.keysTested,
+		AudioBuffer: cpu.audioBuffer,
+		HasAudio:    cpu.hasAudio,
+		RandomTap:   uint16(cpu.random.tap),
+		RandomFeed:  uint16(cpu.random.feed),
+		Random:      cpu.random.vec,
+		PC:          cpu.PC,
+		V:           cpu.V,
+		I:           cpu.I,
+		DT:          cpu.DT,
+		ST:          cpu.ST,
+		RPL:         cpu.RPL,
+		Opcode:      cpu.opcode,
+		Draw:        cpu.draw,
+		KeyWait:     cpu.keyWait,
+		KeyReg:      cpu.keyReg,
+		KeyWaitKey:  int8(cpu.keyWaitKey),
+		Quirks: [7]bool{cpu.QuirkLoadStore, cpu.QuirkShift, cpu.QuirkJump, cpu.QuirkVfOrder, cpu.QuirkDraw,
+			cpu.quirkPartialWrapH, cpu.quirkPartialWrapV},
+	}
+
+	var buf bytes.Buffer
+	if err := binary.Write(&buf, binary.LittleEndian, &state); err != nil {
+		return nil, err
+	}
+	vmem, err := cpu.vmem.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	buf.Write(vmem)
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary restores the state encoded by MarshalBinary
+func (cpu *CPU) UnmarshalBinary(data []byte) error {
+	var state cpuState
+	r := bytes.NewReader(data)
+	if err := binary.Read(r, binary.LittleEndian, &state); err != nil || state.Magic != stateMagic {
+		return errors.New("invalid CPU state")
+	}
+	if state.Version != stateVersion {
+		return errors.New("unsupported CPU state version")
+	}
+	if state.RandomTap >= randomLen || state.RandomFeed >= randomLen {
+		return errors.New("invalid CPU state")
+	}
+	if err := cpu.vmem.UnmarshalBinary(data[len(data)-r.Len():]); err != nil {
+		return err
+	}
+
+	cpu.mem = state.Mem
+	cpu.stack = state.Stack
+	cpu.sp = state.SP
+	cpu.keys = state.Keys
+	cpu.keysLatched = state.KeysLatched
+	cpu.keysTested = state.KeysTested
+	cpu.audioBuffer = state.AudioBuffer
+	cpu.hasAudio = state.HasAudio
+	cpu.random.tap = int(state.RandomTap)
+	cpu.random.feed = int(state.RandomFeed)
+	cpu.random.vec = state.Random
+	cpu.PC = state.PC
+	cpu.V = state.V
+	cpu.I = state.I
+	cpu.DT = state.DT
+	cpu.ST = state.ST
+	cpu.RPL = state.RPL
+	cpu.opcode = state.Opcode
+	cpu.draw = state.Draw
+	cpu.keyWait = state.KeyWait
+	cpu.keyReg = state.KeyReg
+	cpu.keyWaitKey = int(state.KeyWaitKey)
+	q := state.Quirks
+	cpu.QuirkLoadStore, cpu.QuirkShift, cpu.QuirkJump, cpu.QuirkVfOrder, cpu.QuirkDraw = q[0], q[1], q[2], q[3], q[4]
+	cpu.quirkPartialWrapH, cpu.quirkPartialWrapV = q[5], q[6]
+	return nil
+}
@@ -0,0 +1,267 @@
+package detect
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/philw07/pich8-go/internal/cpu"
+)
+
+const (
+	startAddress = 0x200
+
+	// ROMs larger than the memory of CHIP-8 and SCHIP require XO-CHIP
+	maxChip8Size = 0x1000 - startAddress
+)
+
+// Result is the proposed platform profile and quirks of a ROM
+type Result struct {
+	Profile string
+	Quirks  cpu.Quirks
+
+	// Confidence between 0 and 1
+	Confidence float64
+
+	// Reasons lists the findings which led to the result
+	Reasons []string
+}
+
+func (r *Result) String() string {
+	return fmt.Sprintf("%v (%.0f%%)", r.Profile, r.Confidence*100)
+}
+
+// analysis collects the findings while walking the code
+type analysis struct {
+	rom     []byte
+	visited map[int]bool
+	schip   map[string]bool
+	xochip  map[string]bool
+
+	// Quirk evidence, positive values favor the quirk
+	loadStore int
+	shift     int
+	jump      int
+
+	instructions int
+}
+
+// Analyze examines the reachable code of the given ROM and proposes a platform profile and quirks.
+// The code is followed from the start address, so data between the instructions isn't misinterpreted.
+func Analyze(rom []byte) *Result {
+	a := analysis{
+		rom:     rom,
+		visited: map[int]bool{},
+		schip:   map[string]bool{},
+		xochip:  map[string]bool{},
+	}
+	a.walk(startAddress)
+
+	res := Result{Profile: "chip8", Confidence: 0.5}
+	switch {
+	case len(a.xochip) > 0:
+		res.Profile = "xochip"
+		res.Confidence = 0.8 + 0.05*float64(len(a.xochip))
+		for _, op := range sortedKeys(a.xochip) {
+			res.Reasons = append(res.Reasons, fmt.Sprintf("XO-CHIP opcode %v", op))
+		}
+	case len(rom) > maxChip8Size:
+		res.Profile = "xochip"
+		res.Confidence = 0.7
+	case len(a.schip) > 0:
+		res.Profile = "schip"
+		res.Confidence = 0.7 + 0.05*float64(len(a.schip))
+		for _, op := range sortedKeys(a.schip) {
+			res.Reasons = append(res.Reasons, fmt.Sprintf("SCHIP opcode %v", op))
+		}
+	default:
+		res.Reasons = append(res.Reasons, "only CHIP-8 opcodes")
+
+		// The absence of extended opcodes means little if hardly any code was found
+		if a.instructions < 8 {
+			res.Confidence /= 2
+			res.Reasons = append(res.Reasons, fmt.Sprintf("only %v reachable instructions", a.instructions))
+		}
+	}
+	if len(rom) > maxChip8Size {
+		res.Reasons = append(res.Reasons, fmt.Sprintf("size of %v bytes exceeds 3.5 KB", len(rom)))
+	}
+	if res.Confidence > 0.95 {
+		res.Confidence = 0.95
+	}
+
+	res.Quirks = cpu.Profiles[res.Profile]
+	if a.loadStore < 0 {
+		res.Quirks.LoadStore = false
+		res.Reasons = append(res.Reasons, "I is reused after FX55/FX65")
+	}
+	if a.shift > 0 {
+		res.Quirks.Shift = true
+		res.Reasons = append(res.Reasons, "8XY6/8XYE don't set VY before shifting")
+	} else if a.shift < 0 {
+		res.Quirks.Shift = false
+		res.Reasons = append(res.Reasons, "8XY6/8XYE set VY before shifting")
+	}
+	if a.jump > 0 {
+		res.Quirks.Jump = true
+		res.Reasons = append(res.Reasons, "BXNN uses VX")
+	} else if a.jump < 0 {
+		res.Quirks.Jump = false
+		res.Reasons = append(res.Reasons, "BNNN uses V0")
+	}
+
+	return &res
+}
+
+// opcode returns the opcode at the given address or false if it's outside the ROM
+func (a *analysis) opcode(addr int) (uint16, bool) {
+	offset := addr - startAddress
+	if offset < 0 || offset+1 >= len(a.rom) {
+		return 0, false
+	}
+	return uint16(a.rom[offset])<<8 | uint16(a.rom[offset+1]), true
+}
+
+// size returns the size of the instruction at the given address
+func (a *analysis) size(addr int) int {
+	if op, _ := a.opcode(addr); op == 0xF000 {
+		return 4
+	}
+	return 2
+}
+
+// walk follows the code from the given address
+func (a *analysis) walk(addr int) {
+	// Register which was written by the previous instruction, -1 if none
+	lastWritten := -1
+
+	for !a.visited[addr] {
+		op, ok := a.opcode(addr)
+		if !ok {
+			return
+		}
+		a.visited[addr] = true
+		a.instructions++
+
+		x := int(op >> 8 & 0xF)
+		y := int(op >> 4 & 0xF)
+		n := op & 0xF
+		nn := op & 0xFF
+		nnn := int(op & 0xFFF)
+		next := addr + a.size(addr)
+		written := -1
+
+		switch op >> 12 {
+		case 0x0:
+			switch {
+			case op == 0x00EE:
+				return
+			case op == 0x00FD:
+				a.schip["00FD"] = true
+				return
+			case op == 0x00FE || op == 0x00FF:
+				a.schip[fmt.Sprintf("%04X", op)] = true
+			case op == 0x00FB || op == 0x00FC || op&0xFFF0 == 0x00C0:
+				a.schip["00CN/00FB/00FC"] = true
+			case op&0xFFF0 == 0x00D0:
+				a.xochip["00DN"] = true
+			}
+		case 0x1:
+			if nnn == addr {
+				return
+			}
+			addr = nnn
+			lastWritten = -1
+			continue
+		case 0x2:
+			a.walk(nnn)
+		case 0x3, 0x4, 0x9:
+			a.walk(next + a.size(next))
+		case 0x5:
+			switch n {
+			case 0:
+				a.walk(next + a.size(next))
+			case 2, 3:
+				a.xochip["5XY2/5XY3"] = true
+			}
+		case 0x6, 0x7, 0xC:
+			written = x
+		case 0x8:
+			written = x
+			if n == 6 || n == 0xE {
+				if x != y && lastWritten == y {
+					a.shift--
+				} else if x != y {
+					a.shift++
+				}
+			}
+		case 0xB:
+			// Indirect jump, the target can't be followed
+			if hi := nnn >> 8; hi != 0 && lastWritten == hi {
+				a.jump++
+			} else if lastWritten == 0 {
+				a.jump--
+			}
+			return
+		case 0xD:
+			if n == 0 {
+				a.schip["DXY0"] = true
+			}
+		case 0xE:
+			a.walk(next + a.size(next))
+		case 0xF:
+			switch {
+			case op == 0xF000:
+				a.xochip["F000"] = true
+			case op == 0xF002:
+				a.xochip["F002"] = true
+			case nn == 0x01:
+				a.xochip["FN01"] = true
+			case nn == 0x30:
+				a.schip["FX30"] = true
+			case nn == 0x75 || nn == 0x85:
+				a.schip["FX75/FX85"] = true
+			case nn == 0x55 || nn == 0x65:
+				if a.reusesI(next) {
+					a.loadStore--
+				}
+				if nn == 0x65 {
+					written = x
+				}
+			case nn == 0x07 || nn == 0x0A:
+				written = x
+			}
+		}
+
+		lastWritten = written
+		addr = next
+	}
+}
+
+// reusesI returns whether the instructions following the given address access memory via I before setting it
+func (a *analysis) reusesI(addr int) bool {
+	for i := 0; i < 4; i++ {
+		op, ok := a.opcode(addr)
+		if !ok {
+			return false
+		}
+		switch {
+		case op&0xF000 == 0xA000, op == 0xF000, op&0xF0FF == 0xF029, op&0xF0FF == 0xF030:
+			return false
+		case op&0xF0FF == 0xF055, op&0xF0FF == 0xF065, op&0xF0FF == 0xF033, op&0xF000 == 0xD000:
+			return true
+		case op&0xF000 == 0x1000, op&0xF000 == 0x2000, op&0xF000 == 0xB000, op == 0x00EE:
+			return false
+		}
+		addr += a.size(addr)
+	}
+	return false
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := []string{}
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,74 @@
+package detect
+
+import (
+	"testing"
+
+	"github.com/philw07/pich8-go/internal/cpu"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyze(t *testing.T) {
+	assert := assert.New(t)
+
+	// Plain CHIP-8 with the original load/store and shift behavior
+	chip8 := []byte{
+		0xA3, 0x00, // I = 0x300
+		0xF1, 0x65, // load V0..V1
+		0xF1, 0x65, // load V0..V1 with incremented I
+		0x62, 0x01, // V2 = 1
+		0x81, 0x26, // V1 = V2 >> 1
+		0x60, 0x05, // V0 = 5
+		0xD0, 0x15, // draw(V0, V1, 5)
+		0x12, 0x0E, // loop
+	}
+	res := Analyze(chip8)
+	assert.Equal("chip8", res.Profile)
+	assert.Equal(0.5, res.Confidence)
+	expected := cpu.Profiles["chip8"]
+	assert.Equal(expected, res.Quirks)
+	assert.Contains(res.Reasons, "I is reused after FX55/FX65")
+	assert.Contains(res.Reasons, "8XY6/8XYE set VY before shifting")
+
+	// SCHIP opcodes behind a skip, data after the loop is ignored
+	schip := []byte{
+		0x00, 0xFF, // high resolution
+		0x30, 0x00, // skip if V0 == 0
+		0xF0, 0x30, // big font
+		0x6A, 0x10, // VA = 0x10
+		0xBA, 0x20, // jump to 0xA20 + VA
+		0x00, 0xD1, // data, would be XO-CHIP scroll up
+	}
+	res = Analyze(schip)
+	assert.Equal("schip", res.Profile)
+	assert.InDelta(0.8, res.Confidence, 0.001)
+	assert.Equal([]string{"SCHIP opcode 00FF", "SCHIP opcode FX30", "BXNN uses VX"}, res.Reasons)
+	assert.True(res.Quirks.Jump)
+
+	// XO-CHIP opcodes in a subroutine, the long instruction is skipped as a whole
+	xochip := []byte{
+		0x22, 0x0A, // call 0x20A
+		0x40, 0x00, // skip if V0 != 0
+		0xF0, 0x00, 0x12, 0x34, // I = 0x1234
+		0x12, 0x00, // loop
+		0xF2, 0x01, // plane 2
+		0x50, 0x12, // save V0..V1
+		0x00, 0xEE, // return
+	}
+	res = Analyze(xochip)
+	assert.Equal("xochip", res.Profile)
+	assert.Equal(cpu.Profiles["xochip"], res.Quirks)
+	assert.Len(res.Reasons, 3)
+	assert.Equal("xochip (95%)", res.String())
+
+	// Large ROMs require XO-CHIP
+	large := make([]byte, 4000)
+	copy(large, chip8)
+	res = Analyze(large)
+	assert.Equal("xochip", res.Profile)
+	assert.Equal(0.7, res.Confidence)
+
+	// Hardly any code
+	res = Analyze([]byte{0x12, 0x00})
+	assert.Equal("chip8", res.Profile)
+	assert.Equal(0.25, res.Confidence)
+}
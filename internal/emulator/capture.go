@@ -0,0 +1,109 @@
+package emulator
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/philw07/pich8-go/internal/capture"
+	"github.com/philw07/pich8-go/internal/sound"
+	"github.com/philw07/pich8-go/internal/video"
+)
+
+// StartCapture records every emulated frame with the active palette to the given GIF, APNG or Y4M file,
+// a Y4M video is recorded along with a WAV file of the audio
+func (emu *Emulator) StartCapture(path string) error {
+	if err := emu.StopCapture(); err != nil {
+		return err
+	}
+
+	format, err := capture.FormatFromPath(path)
+	if err != nil {
+		return err
+	}
+	recorder, err := capture.Create(path, format)
+	if err != nil {
+		return err
+	}
+	if format == capture.Y4M {
+		// The audio is rendered from the emulated sound timer, one tick per frame
+		emu.captureAudio, err = sound.NewRecorder(strings.TrimSuffix(path, filepath.Ext(path)) + ".wav")
+		if err != nil {
+			recorder.Close()
+			return err
+		}
+	}
+
+	emu.capture = recorder
+	emu.capturePath = path
+	emu.captureFrame(emu.cpu.ST)
+	emu.display.DisplayNotification("Recording " + filepath.Base(path))
+	return nil
+}
+
+// StopCapture finishes the recording, does nothing if no recording is active
+func (emu *Emulator) StopCapture() error {
+	if emu.capture == nil {
+		return nil
+	}
+
+	err := emu.capture.Close()
+	if emu.captureAudio != nil {
+		if audioErr := emu.captureAudio.Close(); err == nil {
+			err = audioErr
+		}
+	}
+	emu.capture = nil
+	emu.captureAudio = nil
+	if err != nil {
+		return fmt.Errorf("failed to record %v: %v", emu.capturePath, err)
+	}
+	emu.display.DisplayNotification("Recorded " + filepath.Base(emu.capturePath))
+	return nil
+}
+
+func (emu *Emulator) toggleCapture() {
+	if emu.capture != nil {
+		if err := emu.StopCapture(); err != nil {
+			emu.showError(err)
+		}
+		return
+	}
+
+	name := strings.TrimSuffix(video.ScreenshotName(emu.romTitle(), time.Now()), ".png")
+	path := video.UniquePath(filepath.Join(emu.settings.ScreenshotDir(), name+emu.settings.CaptureFormat.Extension()))
+	if err := emu.StartCapture(path); err != nil {
+		emu.showError(err)
+	}
+}
+
+// captureFrame adds the current frame to the recording along with the sound of the timer tick,
+// it's called once per timer tick with the sound timer before the tick
+func (emu *Emulator) captureFrame(st byte) {
+	if emu.capture == nil {
+		return
+	}
+
+	// The frames keep the doubled low resolution pixels, so switching to the high resolution doesn't lose detail
+	vmem := emu.cpu.Vmem()
+	emu.captureImage = emu.palette.Render(&vmem, emu.captureImage)
+	img := emu.captureImage
+	if emu.settings.CaptureScale > 1 {
+		img = video.Resize(emu.captureImage, 1, emu.settings.CaptureScale)
+	}
+	err := emu.capture.Frame(img)
+	if err == nil && emu.captureAudio != nil {
+		emu.captureAudio.SetTone(emu.sound.Waveform(), emu.sound.Frequency())
+		err = emu.captureAudio.Tick(st, emu.cpu.AudioBuffer())
+	}
+	if err != nil {
+		emu.capture.Close()
+		if emu.captureAudio != nil {
+			emu.captureAudio.Close()
+		}
+		emu.capture = nil
+		emu.captureAudio = nil
+		emu.showError(fmt.Errorf("failed to record %v: %v", emu.capturePath, err))
+	}
+}
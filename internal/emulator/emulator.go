@@ -2,34 +2,83 @@ package emulator
 
 import (
 	"fmt"
+	"image"
 	"io/ioutil"
+	"math"
+	"path/filepath"
 	"time"
 
-	"github.com/faiface/pixel/pixelgl"
+	"github.com/philw07/pich8-go/internal/capture"
+	"github.com/philw07/pich8-go/internal/config"
 	"github.com/philw07/pich8-go/internal/cpu"
 	"github.com/philw07/pich8-go/internal/data"
+	"github.com/philw07/pich8-go/internal/input"
+	"github.com/philw07/pich8-go/internal/movie"
+	"github.com/philw07/pich8-go/internal/palette"
+	"github.com/philw07/pich8-go/internal/romdb"
 	"github.com/philw07/pich8-go/internal/sound"
-	"github.com/sqweek/dialog"
 )
 
 const (
 	timerFrequency = 60
 	nanosPerTimer  = 1_000_000_000 / timerFrequency
+
+	volumeStep        = 0.05
+	toneFrequencyStep = 20
 )
 
-var cpuSpeeds = [...]int{420, 600, 720, 900, 1200}
+// cpuSpeeds are the CPU speeds selected by the speed commands, the higher speeds are for the XO-CHIP ROMs
+var cpuSpeeds = [...]int{420, 600, 720, 900, 1200, 21000, 30000, 36000, 45000, 60000}
 
 // Emulator implements the CHIP-8 emulator
 type Emulator struct {
-	cpu         cpu.CPU
-	cpuSpeedIdx int
-	cpuMult     bool
-	display     Display
-	input       [16]bool
-	sound       sound.AudioPlayer
-
-	rom  []byte
-	mute bool
+	cpu        cpu.CPU
+	cpuSpeed   int
+	display    Display
+	in         Input
+	held       map[string]bool
+	keyEvents  input.Queue
+	keypad     [16][]input.Binding
+	commands   map[input.Action][]input.Binding
+	macros     [][]input.Binding
+	processor  input.Processor
+	inputStart time.Time
+	sound      Audio
+	hasPattern bool
+	stats      PerfStats
+	palette    palette.Palette
+	quit       bool
+	ticks      uint64
+
+	rom          []byte
+	romName      string
+	romDirectory string
+	quirks       cpu.Quirks
+	settings     config.Settings
+	database     *romdb.Database
+	noDetection  bool
+	explicit     ExplicitSettings
+	romInfo      *romdb.Entry
+	romSpecific  bool
+	themes       []palette.Theme
+	flagsFile    string
+	flags        [16]byte
+	lastFlags    [16]byte
+	defaults     romSettings
+	romKeypad    [16][]input.Binding
+	seed         int64
+	seeded       bool
+
+	recording     *movie.Movie
+	recordingFile string
+	capture       capture.Recorder
+	captureAudio  *sound.Recorder
+	capturePath   string
+	captureImage  *image.RGBA
+	playback      *movie.Movie
+	player        *movie.Player
+	frame         int
+	frameStart    time.Time
 
 	lastCycle           time.Time
 	lastCorrectionCPU   time.Time
@@ -41,87 +90,197 @@ type Emulator struct {
 	pauseTime           time.Time
 }
 
-// NewEmulator creates a new instance
-func NewEmulator() (*Emulator, error) {
-	disp, err := NewDisplay()
-	if err != nil {
+// NewEmulator creates a new instance with the given settings and key bindings, which runs on the given frontend
+func NewEmulator(settings *config.Settings, bindings *input.Bindings, frontend Frontend) (*Emulator, error) {
+	if err := checkBindings(bindings, frontend.Input); err != nil {
 		return nil, err
 	}
+	macros := [][]input.Binding{}
+	for _, macro := range bindings.Macros {
+		macros = append(macros, macro.Keys)
+	}
 
 	now := time.Now()
 	emu := Emulator{
-		cpu:         *cpu.NewCPU(),
-		cpuSpeedIdx: 2,
-		display:     *disp,
-		sound:       *sound.NewAudioPlayer(),
-
-		rom: data.BootRom[:],
+		cpu:        *cpu.NewCPU(),
+		cpuSpeed:   settings.Speed,
+		display:    frontend.Display,
+		in:         frontend.Input,
+		held:       map[string]bool{},
+		keypad:     bindings.Keypad,
+		commands:   bindings.Commands,
+		macros:     macros,
+		processor:  *input.NewProcessor(bindings),
+		inputStart: now,
+		themes:     palette.Themes,
+		sound:      frontend.Audio,
+		stats:      *NewPerfStats(),
+		palette:    settings.Palette,
+
+		rom:          data.BootRom[:],
+		romName:      "bootrom",
+		romDirectory: settings.RomDirectory,
+		quirks:       settings.Quirks,
+		settings:     *settings,
 
 		lastCycle:           now,
 		lastCorrectionCPU:   now,
 		lastTimer:           now,
 		lastCorrectionTimer: now,
 	}
+	emu.sound.SetVolume(settings.Volume)
+	emu.sound.SetMute(settings.Muted)
 	emu.reset()
 
 	return &emu, nil
 }
 
+// Settings returns the current settings
+func (emu *Emulator) Settings() *config.Settings {
+	settings := emu.settings
+	settings.Speed = emu.getCPUSpeed()
+	settings.Quirks = emu.quirks
+	settings.Palette = emu.palette
+	if emu.romSpecific {
+		// The settings of the ROM database or the detection aren't saved
+		settings.Speed = emu.defaults.speed
+		settings.Quirks = emu.defaults.quirks
+		settings.Palette = emu.defaults.palette
+	}
+	emu.display.UpdateSettings(&settings)
+	settings.Volume = emu.sound.Volume()
+	settings.Muted = emu.sound.Muted()
+	settings.RomDirectory = emu.romDirectory
+	return &settings
+}
+
+// SetSeed seeds the random number generator with the given value on every reset instead of the current time
+func (emu *Emulator) SetSeed(seed int64) {
+	emu.seed = seed
+	emu.seeded = true
+	emu.cpu.Seed(seed)
+}
+
+// SetPaused pauses or resumes the emulation
+func (emu *Emulator) SetPaused(pause bool) {
+	if pause != emu.pause {
+		emu.setPause(pause)
+	}
+}
+
 func (emu *Emulator) reset() error {
 	emu.cpu = *cpu.NewCPU()
+	emu.cpu.SetQuirks(emu.quirks)
+	if emu.seeded {
+		emu.cpu.Seed(emu.seed)
+	}
 	if err := emu.cpu.LoadRom(emu.rom); err != nil {
 		return err
 	}
+	emu.cpu.RPL = emu.flags
+	emu.lastFlags = emu.flags
+	emu.hasPattern = false
+	emu.sound.SetSoundTimer(0)
+
+	// Keep keys which are held down
+	for key, pressed := range emu.keyEvents.State() {
+		if pressed {
+			emu.cpu.SetKey(byte(key), true)
+		}
+	}
 	return nil
 }
 
 // LoadRom loads the given ROM into the emulator
 func (emu *Emulator) LoadRom(rom []byte) error {
-	emu.display.DisplayInstructions = false
+	emu.stopMovie()
 	emu.rom = rom
+	emu.display.Reset()
+	emu.applyRomInfo()
+	emu.loadFlags()
 	return emu.reset()
 }
 
+// LoadRomFile loads the ROM from the given file into the emulator
+func (emu *Emulator) LoadRomFile(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := emu.LoadRom(data); err != nil {
+		return err
+	}
+	emu.romName = filepath.Base(path)
+	if dir, err := filepath.Abs(filepath.Dir(path)); err == nil {
+		emu.romDirectory = dir
+	}
+	return nil
+}
+
 func (emu *Emulator) setPause(pause bool) {
 	emu.pause = pause
 	if pause {
 		// Store timestamp
 		emu.pauseTime = time.Now()
+		// The gated tone would play on while the timers stand still
+		emu.sound.SetSoundTimer(0)
 	} else {
 		// "Subtract" paused time so the emulation doesn't jump
 		diff := time.Since(emu.pauseTime)
 		emu.lastCycle = emu.lastCycle.Add(diff)
+		emu.frameStart = emu.frameStart.Add(diff)
+		// The tone continues for the remaining timer ticks
+		if !emu.hasPattern {
+			emu.sound.SetSoundTimer(emu.cpu.ST)
+		}
 	}
 }
 
 func (emu *Emulator) getCPUSpeed() int {
-	speed := cpuSpeeds[emu.cpuSpeedIdx]
-	if emu.cpuMult {
-		speed *= 50
-	}
-	return speed
+	return emu.cpuSpeed
 }
 
 // Run runs the main loop of the emulator
 func (emu *Emulator) Run() {
-	for !emu.display.Window.Closed() {
+	for !emu.quit && !emu.display.Closed() {
 		// Handle input
 		emu.handleInput()
 
 		// Perform emulation
 		emu.performEmulation()
+		emu.saveFlags()
 
 		// Draw the frame
-		emu.display.Draw(emu.cpu.Vmem())
+		emu.stats.Frame(emu.getCPUSpeed(), emu.sound.QueueLatency())
+		emu.display.Draw(&Frame{
+			Vmem:    emu.cpu.Vmem(),
+			Palette: emu.palette,
+			Stats:   &emu.stats,
+			Paused:  emu.pause,
+			Ticks:   emu.ticks,
+			Audio:   emu.audioText(),
+			Keypad:  KeypadState{Pressed: emu.cpu.PressedKeys(), Tested: emu.cpu.TestedKeys()},
+		})
+	}
+
+	emu.stopMovie()
+	if err := emu.StopCapture(); err != nil {
+		emu.showError(err)
+	}
+	if err := emu.sound.Close(); err != nil {
+		emu.showError(err)
 	}
 }
 
 func (emu *Emulator) performEmulation() {
-	if !emu.pause {
+	if !emu.pause && emu.movieActive() {
+		emu.performMovieFrames()
+	} else if !emu.pause {
 		// Emulate CPU cycles
 		nanosPerCycle := 1_000_000_000 / emu.getCPUSpeed()
 		if time.Since(emu.lastCycle).Nanoseconds() >= 10*int64(nanosPerCycle) {
 			cycles := int(time.Since(emu.lastCycle).Nanoseconds()) / nanosPerCycle
+			start := emu.lastCycle
 			emu.lastCycle = time.Now()
 
 			// Check if additional cycles are needed
@@ -129,6 +288,7 @@ func (emu *Emulator) performEmulation() {
 				target := emu.getCPUSpeed() / 4
 				if emu.counterCPU < target {
 					cycles += target - emu.counterCPU
+					emu.stats.AddCorrection(target-emu.counterCPU, 0)
 				}
 				emu.lastCorrectionCPU = time.Now()
 				emu.counterCPU = 0
@@ -136,9 +296,9 @@ func (emu *Emulator) performEmulation() {
 				emu.counterCPU += cycles
 			}
 
-			for i := 0; i < int(cycles); i++ {
-				emu.cpu.Tick(emu.input)
-			}
+			emu.runCycles(cycles, start, emu.lastCycle)
+			emu.stats.AddInstructions(cycles)
+			emu.updateSound()
 		}
 
 		// Update timers
@@ -151,6 +311,7 @@ func (emu *Emulator) performEmulation() {
 				target := timerFrequency / 4
 				if emu.counterTimer+1 < target {
 					reps += target - emu.counterTimer - 1
+					emu.stats.AddCorrection(0, target-emu.counterTimer-1)
 				}
 				emu.lastCorrectionTimer = time.Now()
 				emu.counterTimer = 0
@@ -159,130 +320,240 @@ func (emu *Emulator) performEmulation() {
 			}
 
 			for i := 0; i < reps; i++ {
-				if emu.cpu.ST > 0 && !emu.mute {
-					if emu.cpu.AudioBuffer() != nil {
-						emu.sound.PlayBuffer(*emu.cpu.AudioBuffer())
-					} else {
-						emu.sound.Beep()
-					}
-				}
-				emu.cpu.UpdateTimers()
+				emu.tickTimers()
 			}
+			emu.stats.AddTimerTicks(reps)
+			emu.updateSound()
 		}
 	}
 }
 
-func (emu *Emulator) handleInput() {
-	// CHIP-8 keys
-	emu.input[1] = emu.display.Window.Pressed(pixelgl.Key1)
-	emu.input[2] = emu.display.Window.Pressed(pixelgl.Key2)
-	emu.input[3] = emu.display.Window.Pressed(pixelgl.Key3)
-	emu.input[0xC] = emu.display.Window.Pressed(pixelgl.Key4)
-	emu.input[4] = emu.display.Window.Pressed(pixelgl.KeyQ)
-	emu.input[5] = emu.display.Window.Pressed(pixelgl.KeyW)
-	emu.input[6] = emu.display.Window.Pressed(pixelgl.KeyE)
-	emu.input[0xD] = emu.display.Window.Pressed(pixelgl.KeyR)
-	emu.input[7] = emu.display.Window.Pressed(pixelgl.KeyA)
-	emu.input[8] = emu.display.Window.Pressed(pixelgl.KeyS)
-	emu.input[9] = emu.display.Window.Pressed(pixelgl.KeyD)
-	emu.input[0xE] = emu.display.Window.Pressed(pixelgl.KeyF)
-	emu.input[0xA] = emu.display.Window.Pressed(pixelgl.KeyZ)
-	emu.input[0] = emu.display.Window.Pressed(pixelgl.KeyX)
-	emu.input[0xB] = emu.display.Window.Pressed(pixelgl.KeyC)
-	emu.input[0xF] = emu.display.Window.Pressed(pixelgl.KeyV)
+// tickTimers plays the XO-CHIP audio of a timer tick, updates the timers and adds the frame to the capture
+func (emu *Emulator) tickTimers() {
+	st := emu.cpu.ST
+	if st > 0 && emu.cpu.AudioBuffer() != nil {
+		emu.sound.PlayBuffer(*emu.cpu.AudioBuffer())
+	}
+	emu.cpu.UpdateTimers()
+	emu.ticks++
+	emu.captureFrame(st)
+}
 
-	// Commands
+// runCycles executes the given number of cycles spread evenly over the given time span,
+// so that the queued key events reach the CPU right before the instruction at their time
+func (emu *Emulator) runCycles(cycles int, from, to time.Time) {
+	step := to.Sub(from) / time.Duration(cycles)
+	for i := 0; i < cycles; i++ {
+		emu.applyKeyEvents(from.Add(time.Duration(i) * step))
+		emu.cpu.Step()
+	}
+	emu.applyKeyEvents(to)
+}
 
-	if emu.display.Window.Pressed(pixelgl.KeyLeftControl) || emu.display.Window.Pressed(pixelgl.KeyRightControl) {
-		if emu.display.Window.JustPressed(pixelgl.KeyO) {
-			emu.setPause(true)
-			defer emu.setPause(false)
-
-			file, err := dialog.File().Title("Open ROM...").Load()
-			if err == nil {
-				data, err := ioutil.ReadFile(file)
-				if err != nil {
-					dialog.Message(fmt.Sprintf("Error occurred: %v", err)).Title("Error").Error()
-				} else {
-					err = emu.LoadRom(data)
-					if err != nil {
-						dialog.Message(fmt.Sprintf("Error occurred: %v", err)).Title("Error").Error()
-					}
-				}
-			}
-		}
-		if emu.display.Window.JustPressed(pixelgl.Key1) {
-			emu.cpu.QuirkLoadStore = !emu.cpu.QuirkLoadStore
-			emu.display.DisplayNotification(emu.quirkText("Load/store quirk", emu.cpu.QuirkLoadStore))
-		}
-		if emu.display.Window.JustPressed(pixelgl.Key2) {
-			emu.cpu.QuirkShift = !emu.cpu.QuirkShift
-			emu.display.DisplayNotification(emu.quirkText("Shift quirk", emu.cpu.QuirkShift))
-		}
-		if emu.display.Window.JustPressed(pixelgl.Key3) {
-			emu.cpu.QuirkJump = !emu.cpu.QuirkJump
-			emu.display.DisplayNotification(emu.quirkText("Jump quirk", emu.cpu.QuirkJump))
-		}
-		if emu.display.Window.JustPressed(pixelgl.Key4) {
-			emu.cpu.QuirkVfOrder = !emu.cpu.QuirkVfOrder
-			emu.display.DisplayNotification(emu.quirkText("VF order quirk", emu.cpu.QuirkVfOrder))
-		}
-		if emu.display.Window.JustPressed(pixelgl.Key5) {
-			emu.cpu.QuirkDraw = !emu.cpu.QuirkDraw
-			emu.display.DisplayNotification(emu.quirkText("Draw quirk", emu.cpu.QuirkDraw))
+func (emu *Emulator) applyKeyEvents(until time.Time) {
+	for {
+		event, ok := emu.keyEvents.Next(until)
+		if !ok {
+			return
 		}
-	} else {
-		if emu.display.Window.JustPressed(pixelgl.KeyEscape) {
-			emu.display.Window.SetClosed(true)
-		}
-		if emu.display.Window.JustPressed(pixelgl.KeyF1) {
-			emu.display.DisplayInstructions = !emu.display.DisplayInstructions
-		}
-		if emu.display.Window.JustPressed(pixelgl.KeyF2) {
-			emu.display.DisplayFps = !emu.display.DisplayFps
-		}
-		if emu.display.Window.JustPressed(pixelgl.KeyF3) {
-			emu.display.ToggleVSync()
+		emu.cpu.SetKey(event.Key, event.Pressed)
+	}
+}
+
+// updateSound gates the tone whenever the ROM set the sound timer, XO-CHIP audio buffers are played instead of the tone
+func (emu *Emulator) updateSound() {
+	written := emu.cpu.SoundTimerWritten()
+	hasPattern := emu.cpu.AudioBuffer() != nil
+	if !written && hasPattern == emu.hasPattern {
+		return
+	}
+	emu.hasPattern = hasPattern
+
+	st := emu.cpu.ST
+	if hasPattern {
+		st = 0
+	}
+	emu.sound.SetSoundTimer(st)
+}
+
+// keypadState returns the states of the CHIP-8 keys at the given time
+func (emu *Emulator) keypadState(t time.Time) [16]bool {
+	var keys [16]bool
+	for key := range emu.keypad {
+		keys[key] = anyHeld(emu.held, emu.keypad[key]) || anyHeld(emu.held, emu.romKeypad[key])
+	}
+
+	// Virtual keypad
+	if key, ok := emu.in.KeypadKey(); ok {
+		keys[key] = true
+	}
+
+	// Autofire and macros are timed in frames at the timer frequency
+	return emu.processor.Process(keys, emu.inputFrame(t))
+}
+
+// inputFrame returns the frame at the timer frequency of the given time
+func (emu *Emulator) inputFrame(t time.Time) int {
+	return int(t.Sub(emu.inputStart) / (time.Second / timerFrequency))
+}
+
+func (emu *Emulator) handleInput() {
+	// CHIP-8 keys, the events are queued at their times so the emulation sees them in between the instructions of a frame
+	for _, event := range emu.in.KeyEvents() {
+		if event.Pressed {
+			emu.held[event.Key] = true
+		} else {
+			delete(emu.held, event.Key)
 		}
-		if emu.display.Window.JustPressed(pixelgl.KeyF5) {
-			emu.reset()
+		emu.keyEvents.Update(emu.keypadState(event.Time), event.Time)
+	}
+	now := time.Now()
+	emu.keyEvents.Update(emu.keypadState(now), now)
+	frame := emu.inputFrame(now)
+
+	// Commands
+	for _, action := range input.Actions {
+		if justPressed(emu.in, emu.commands[action]) {
+			emu.performAction(action)
 		}
-		if emu.display.Window.JustPressed(pixelgl.KeyF11) {
-			emu.display.ToggleFullscreen()
+	}
+
+	// Macros
+	for index, bindings := range emu.macros {
+		if justPressed(emu.in, bindings) {
+			emu.processor.StartMacro(index, frame)
 		}
-		if emu.display.Window.JustPressed(pixelgl.KeyP) {
-			emu.setPause(!emu.pause)
+	}
+}
+
+func (emu *Emulator) performAction(action input.Action) {
+	if emu.movieActive() {
+		switch action {
+		case input.SpeedUp, input.SpeedDown, input.QuirkLoadStore, input.QuirkShift, input.QuirkJump, input.QuirkVfOrder, input.QuirkDraw:
+			// The movie would no longer be deterministic
+			emu.display.DisplayNotification("Not available during movies")
+			return
 		}
-		if emu.display.Window.JustPressed(pixelgl.KeyM) {
-			emu.mute = !emu.mute
+	}
+
+	switch action {
+	case input.RecordMovie:
+		emu.toggleRecording()
+	case input.OpenRom:
+		emu.openRomDialog()
+	case input.QuirkLoadStore:
+		emu.quirks.LoadStore = !emu.quirks.LoadStore
+		emu.cpu.SetQuirks(emu.quirks)
+		emu.display.DisplayNotification(emu.toggleText("Load/store quirk", emu.quirks.LoadStore))
+	case input.QuirkShift:
+		emu.quirks.Shift = !emu.quirks.Shift
+		emu.cpu.SetQuirks(emu.quirks)
+		emu.display.DisplayNotification(emu.toggleText("Shift quirk", emu.quirks.Shift))
+	case input.QuirkJump:
+		emu.quirks.Jump = !emu.quirks.Jump
+		emu.cpu.SetQuirks(emu.quirks)
+		emu.display.DisplayNotification(emu.toggleText("Jump quirk", emu.quirks.Jump))
+	case input.QuirkVfOrder:
+		emu.quirks.VfOrder = !emu.quirks.VfOrder
+		emu.cpu.SetQuirks(emu.quirks)
+		emu.display.DisplayNotification(emu.toggleText("VF order quirk", emu.quirks.VfOrder))
+	case input.QuirkDraw:
+		emu.quirks.Draw = !emu.quirks.Draw
+		emu.cpu.SetQuirks(emu.quirks)
+		emu.display.DisplayNotification(emu.toggleText("Draw quirk", emu.quirks.Draw))
+	case input.ToneFrequencyUp:
+		emu.sound.SetFrequency(emu.sound.Frequency() + toneFrequencyStep)
+		emu.display.DisplayNotification(fmt.Sprintf("Tone: %vHz", emu.sound.Frequency()))
+	case input.ToneFrequencyDown:
+		emu.sound.SetFrequency(emu.sound.Frequency() - toneFrequencyStep)
+		emu.display.DisplayNotification(fmt.Sprintf("Tone: %vHz", emu.sound.Frequency()))
+	case input.Quit:
+		emu.quit = true
+	case input.Autofire:
+		emu.processor.AutofireEnabled = !emu.processor.AutofireEnabled
+		emu.display.DisplayNotification(emu.toggleText("Autofire", emu.processor.AutofireEnabled))
+	case input.Palette:
+		emu.nextTheme()
+	case input.Capture:
+		emu.toggleCapture()
+	case input.Screenshot:
+		emu.takeScreenshot()
+	case input.Reset:
+		emu.stopMovie()
+		emu.reset()
+	case input.HardReset:
+		emu.stopMovie()
+		if err := emu.clearFlags(); err != nil {
+			emu.showError(err)
 		}
-		if emu.display.Window.JustPressed(pixelgl.KeyPageUp) {
-			if emu.cpuSpeedIdx == len(cpuSpeeds)-1 && !emu.cpuMult {
-				emu.cpuSpeedIdx = 0
-				emu.cpuMult = true
-			} else if emu.cpuSpeedIdx < len(cpuSpeeds)-1 {
-				emu.cpuSpeedIdx++
+		emu.reset()
+		emu.display.DisplayNotification("Reset, flags cleared")
+	case input.Pause:
+		emu.setPause(!emu.pause)
+	case input.Mute:
+		emu.sound.SetMute(!emu.sound.Muted())
+		emu.display.DisplayNotification(emu.toggleText("Mute", emu.sound.Muted()))
+	case input.Waveform:
+		emu.sound.SetWaveform(emu.sound.Waveform() + 1)
+		emu.display.DisplayNotification(fmt.Sprintf("Waveform: %v", emu.sound.Waveform()))
+	case input.VolumeUp:
+		emu.sound.SetVolume(emu.sound.Volume() + volumeStep)
+		emu.display.DisplayNotification(emu.volumeText())
+	case input.VolumeDown:
+		emu.sound.SetVolume(emu.sound.Volume() - volumeStep)
+		emu.display.DisplayNotification(emu.volumeText())
+	case input.SpeedUp:
+		for _, speed := range cpuSpeeds {
+			if speed > emu.cpuSpeed {
+				emu.cpuSpeed = speed
+				break
 			}
-
-			emu.display.DisplayNotification(fmt.Sprintf("CPU Speed: %vHz", emu.getCPUSpeed()))
 		}
-		if emu.display.Window.JustPressed(pixelgl.KeyPageDown) {
-			if emu.cpuSpeedIdx == 0 && emu.cpuMult {
-				emu.cpuSpeedIdx = len(cpuSpeeds) - 1
-				emu.cpuMult = false
-			} else if emu.cpuSpeedIdx > 0 {
-				emu.cpuSpeedIdx--
+		emu.display.DisplayNotification(fmt.Sprintf("CPU Speed: %vHz", emu.getCPUSpeed()))
+	case input.SpeedDown:
+		for i := len(cpuSpeeds) - 1; i >= 0; i-- {
+			if cpuSpeeds[i] < emu.cpuSpeed {
+				emu.cpuSpeed = cpuSpeeds[i]
+				break
 			}
+		}
+		emu.display.DisplayNotification(fmt.Sprintf("CPU Speed: %vHz", emu.getCPUSpeed()))
+	default:
+		emu.display.PerformAction(action)
+	}
+}
 
-			emu.display.DisplayNotification(fmt.Sprintf("CPU Speed: %vHz", emu.getCPUSpeed()))
+func (emu *Emulator) openRomDialog() {
+	emu.setPause(true)
+	defer emu.setPause(false)
+
+	if file, ok := emu.display.OpenFile("Open ROM...", emu.romDirectory); ok {
+		if err := emu.LoadRomFile(file); err != nil {
+			emu.showError(err)
 		}
 	}
 }
 
-func (emu *Emulator) quirkText(quirk string, active bool) string {
+func (emu *Emulator) showError(err error) {
+	emu.display.ShowError(err)
+}
+
+// audioText returns the audio state to be shown in the UI
+func (emu *Emulator) audioText() string {
+	return fmt.Sprintf("%v %vHz, %v", emu.sound.Waveform(), emu.sound.Frequency(), emu.volumeText())
+}
+
+func (emu *Emulator) volumeText() string {
+	if emu.sound.Muted() {
+		return fmt.Sprintf("Volume: %v%% (muted)", math.Round(emu.sound.Volume()*100))
+	}
+	return fmt.Sprintf("Volume: %v%%", math.Round(emu.sound.Volume()*100))
+}
+
+func (emu *Emulator) toggleText(name string, active bool) string {
 	if active {
-		return fmt.Sprintf("%v ON", quirk)
+		return fmt.Sprintf("%v ON", name)
 	}
 
-	return fmt.Sprintf("%v OFF", quirk)
+	return fmt.Sprintf("%v OFF", name)
 }
@@ -0,0 +1,342 @@
+package emulator
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/philw07/pich8-go/internal/config"
+	"github.com/philw07/pich8-go/internal/cpu"
+	"github.com/philw07/pich8-go/internal/input"
+	"github.com/philw07/pich8-go/internal/movie"
+	"github.com/philw07/pich8-go/internal/palette"
+	"github.com/philw07/pich8-go/internal/romdb"
+	"github.com/philw07/pich8-go/internal/sound"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeFrontend implements the display and the input, it closes after the given number of frames
+type fakeFrontend struct {
+	closeAfter int
+	events     []KeyEvent
+	// justPressed is cleared after every frame
+	justPressed map[input.Binding]bool
+
+	frames        int
+	palette       palette.Palette
+	notifications []string
+	actions       []input.Action
+	opened        int
+}
+
+func newFakeFrontend(closeAfter int) *fakeFrontend {
+	return &fakeFrontend{closeAfter: closeAfter, justPressed: map[input.Binding]bool{}}
+}
+
+func (f *fakeFrontend) Draw(frame *Frame) {
+	f.frames++
+	f.palette = frame.Palette
+	f.justPressed = map[input.Binding]bool{}
+}
+
+func (f *fakeFrontend) DisplayNotification(text string) {
+	f.notifications = append(f.notifications, text)
+}
+
+func (f *fakeFrontend) SetTitle(title string) {}
+
+func (f *fakeFrontend) Reset() {}
+
+func (f *fakeFrontend) PerformAction(action input.Action) {
+	f.actions = append(f.actions, action)
+}
+
+func (f *fakeFrontend) UpdateSettings(settings *config.Settings) {
+	settings.VSync = true
+}
+
+func (f *fakeFrontend) OpenFile(title, dir string) (string, bool) {
+	f.opened++
+	return "", false
+}
+
+func (f *fakeFrontend) ShowError(err error) {
+	f.notifications = append(f.notifications, err.Error())
+}
+
+func (f *fakeFrontend) Closed() bool {
+	return f.frames >= f.closeAfter
+}
+
+func (f *fakeFrontend) KnownKey(key string) bool {
+	return key != "Banana"
+}
+
+func (f *fakeFrontend) KeyEvents() []KeyEvent {
+	events := f.events
+	f.events = nil
+	return events
+}
+
+func (f *fakeFrontend) JustPressed(binding input.Binding) bool {
+	return f.justPressed[binding]
+}
+
+func (f *fakeFrontend) KeypadKey() (byte, bool) {
+	return 0, false
+}
+
+func newTestEmulator(t *testing.T, fake *fakeFrontend) *Emulator {
+	bindings, err := input.DefaultBindings("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	frontend := Frontend{Display: fake, Input: fake, Audio: sound.NewAudioPlayer(sound.NewNullSink())}
+	emu, err := NewEmulator(config.Default(), bindings, frontend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return emu
+}
+
+func TestNewEmulatorUnknownKey(t *testing.T) {
+	assert := assert.New(t)
+
+	bindings, _ := input.DefaultBindings("default")
+	bindings.Commands[input.Pause] = append(bindings.Commands[input.Pause], input.Binding{Key: "Banana"})
+	fake := newFakeFrontend(1)
+	frontend := Frontend{Display: fake, Input: fake, Audio: sound.NewAudioPlayer(sound.NewNullSink())}
+	_, err := NewEmulator(config.Default(), bindings, frontend)
+	assert.NotNil(err)
+}
+
+func TestRun(t *testing.T) {
+	assert := assert.New(t)
+
+	fake := newFakeFrontend(3)
+	emu := newTestEmulator(t, fake)
+	emu.Run()
+	assert.Equal(3, fake.frames)
+	assert.Equal(config.Default().Palette, fake.palette)
+
+	// The display stores its own settings
+	assert.True(emu.Settings().VSync)
+}
+
+func TestCommands(t *testing.T) {
+	assert := assert.New(t)
+
+	fake := newFakeFrontend(10)
+	emu := newTestEmulator(t, fake)
+	fake.justPressed[input.Binding{Key: "M"}] = true
+	fake.justPressed[input.Binding{Key: "F11"}] = true
+	emu.handleInput()
+	assert.True(emu.Settings().Muted)
+	assert.Equal([]string{"Mute ON"}, fake.notifications)
+	// The display performs the commands concerning itself
+	assert.Equal([]input.Action{input.Fullscreen}, fake.actions)
+
+	// Commands with Ctrl require the modifier
+	fake.justPressed = map[input.Binding]bool{{Key: "O"}: true}
+	emu.handleInput()
+	assert.Equal(0, fake.opened)
+	fake.justPressed = map[input.Binding]bool{{Key: "O", Ctrl: true}: true}
+	emu.handleInput()
+	assert.Equal(1, fake.opened)
+
+	// Quitting ends the main loop before the display is closed
+	fake.justPressed = map[input.Binding]bool{{Key: "Escape"}: true}
+	emu.Run()
+	assert.Equal(1, fake.frames)
+}
+
+func TestLoadRomInvalidFlags(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "pich8-go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("XDG_CONFIG_HOME", dir)
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+
+	fake := newFakeFrontend(1)
+	emu := newTestEmulator(t, fake)
+	emu.cpu.RPL[0] = 9
+	rom := []byte{0x12, 0x00}
+	flagsFile := config.FlagsFile(movie.RomHash(rom))
+	os.MkdirAll(filepath.Dir(flagsFile), 0755)
+	ioutil.WriteFile(flagsFile, []byte{1, 2, 3}, 0644)
+
+	// The ROM is loaded with cleared flags and the truncated file isn't overwritten with the previous flags
+	assert.Nil(emu.LoadRom(rom))
+	assert.Equal(rom, emu.rom)
+	assert.Equal([16]byte{}, emu.cpu.RPL)
+	assert.Contains(fake.notifications[len(fake.notifications)-1], "Flags not loaded")
+	emu.saveFlags()
+	data, _ := ioutil.ReadFile(flagsFile)
+	assert.Equal([]byte{1, 2, 3}, data)
+}
+
+func TestDetection(t *testing.T) {
+	assert := assert.New(t)
+
+	rom := []byte{
+		0x00, 0xFF, // high resolution
+		0xF0, 0x30, // big font
+		0x6A, 0x10, // VA = 0x10
+		0xBA, 0x20, // jump to 0xA20 + VA
+	}
+	fake := newFakeFrontend(1)
+	emu := newTestEmulator(t, fake)
+	emu.quirks = cpu.Quirks{}
+	assert.Nil(emu.LoadRom(rom))
+	assert.True(emu.quirks.Jump)
+
+	// The previous quirks are restored and stay unchanged without detection
+	fake.notifications = nil
+	emu.SetDetection(false)
+	assert.Nil(emu.LoadRom(rom))
+	assert.Equal(cpu.Quirks{}, emu.quirks)
+	assert.Empty(fake.notifications)
+}
+
+func TestExplicitSettings(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "pich8-go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	rom := []byte{0x12, 0x00}
+	path := filepath.Join(dir, "roms.json")
+	entry := `{"` + movie.RomHash(rom) + `": {"title": "Test", "platform": "superchip", "tickrate": 20, "colors": ["#102030"]}}`
+	if err := ioutil.WriteFile(path, []byte(entry), 0644); err != nil {
+		t.Fatal(err)
+	}
+	db := romdb.New()
+	assert.Nil(db.LoadOverrides(path))
+
+	fake := newFakeFrontend(1)
+	emu := newTestEmulator(t, fake)
+	emu.SetDatabase(db)
+	chip8, _ := cpu.ProfileQuirks("chip8")
+	schip, _ := cpu.ProfileQuirks("schip")
+	emu.quirks = chip8
+	emu.cpuSpeed = 700
+	assert.Nil(emu.LoadRom(rom))
+	assert.Equal(schip, emu.quirks)
+	assert.Equal(1200, emu.cpuSpeed)
+	assert.Equal(palette.Color{R: 0x10, G: 0x20, B: 0x30, A: 0xff}, emu.palette[0])
+
+	// The settings given on the command line win over the entry
+	emu.SetExplicit(ExplicitSettings{Speed: true, Quirks: true, Palette: true})
+	assert.Nil(emu.LoadRom(rom))
+	assert.Equal(chip8, emu.quirks)
+	assert.Equal(700, emu.cpuSpeed)
+	assert.Equal(config.Default().Palette, emu.palette)
+}
+
+// timerAudio records the sound timers set on the audio
+type timerAudio struct {
+	Audio
+	timers []byte
+}
+
+func (a *timerAudio) SetSoundTimer(st byte) {
+	a.timers = append(a.timers, st)
+	a.Audio.SetSoundTimer(st)
+}
+
+func TestPauseSound(t *testing.T) {
+	assert := assert.New(t)
+
+	fake := newFakeFrontend(1)
+	emu := newTestEmulator(t, fake)
+	emu.SetDetection(false)
+	// V0 = 30, ST = V0, loop
+	assert.Nil(emu.LoadRom([]byte{0x60, 0x1E, 0xF0, 0x18, 0x12, 0x04}))
+	audio := &timerAudio{Audio: emu.sound}
+	emu.sound = audio
+	emu.cpu.Step()
+	emu.cpu.Step()
+	emu.updateSound()
+	emu.tickTimers()
+
+	// The tone stops while paused and continues for the remaining ticks
+	emu.SetPaused(true)
+	emu.SetPaused(false)
+	assert.Equal([]byte{30, 0, 29}, audio.timers)
+}
+
+func TestCaptureAudio(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "pich8-go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fake := newFakeFrontend(1)
+	emu := newTestEmulator(t, fake)
+	emu.SetDetection(false)
+	// V0 = 30, ST = V0, loop
+	assert.Nil(emu.LoadRom([]byte{0x60, 0x1E, 0xF0, 0x18, 0x12, 0x04}))
+	emu.cpu.Step()
+	emu.cpu.Step()
+	emu.sound.SetMute(true)
+
+	// The audio has exactly one timer tick per frame, independent of the real-time output
+	assert.Nil(emu.StartCapture(filepath.Join(dir, "clip.y4m")))
+	for i := 0; i < 5; i++ {
+		emu.tickTimers()
+	}
+	assert.Nil(emu.StopCapture())
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "clip.wav"))
+	assert.Nil(err)
+	samplesPerTick := sound.SampleRate / timerFrequency
+	assert.Len(data, 44+6*samplesPerTick*4)
+	assert.NotEqual([]byte{0, 0, 0, 0}, data[len(data)-4:])
+}
+
+func TestShortKeyPress(t *testing.T) {
+	assert := assert.New(t)
+
+	fake := newFakeFrontend(1)
+	emu := newTestEmulator(t, fake)
+	emu.SetDetection(false)
+	rom := []byte{
+		0x61, 0x05, // V1 = 5
+		0xE1, 0x9E, // skip if key V1 is pressed
+		0x12, 0x02, // jump back
+		0x62, 0x01, // V2 = 1
+		0xF3, 0x0A, // V3 = next key
+		0x64, 0x01, // V4 = 1
+		0x12, 0x0C, // loop
+	}
+	assert.Nil(emu.LoadRom(rom))
+	key := emu.keypad[5][0].Key
+
+	// Taps which are pressed and released between two frames reach the emulation at their times
+	tap := func(duration time.Duration) {
+		emu.lastCycle = time.Now().Add(-time.Second / timerFrequency)
+		pressed := emu.lastCycle.Add(4 * time.Millisecond)
+		fake.events = []KeyEvent{{Key: key, Pressed: true, Time: pressed}, {Key: key, Pressed: false, Time: pressed.Add(duration)}}
+		emu.handleInput()
+		emu.performEmulation()
+	}
+	tap(3 * time.Millisecond)
+	assert.EqualValues(1, emu.cpu.V[2])
+	assert.EqualValues(0, emu.cpu.V[4])
+	tap(time.Millisecond)
+	assert.EqualValues(5, emu.cpu.V[3])
+	assert.EqualValues(1, emu.cpu.V[4])
+	assert.Equal([16]bool{}, emu.keyEvents.State())
+}
@@ -0,0 +1,45 @@
+package emulator
+
+import (
+	"fmt"
+
+	"github.com/philw07/pich8-go/internal/config"
+	"github.com/philw07/pich8-go/internal/movie"
+)
+
+// loadFlags loads the saved flag registers of the loaded ROM,
+// if they can't be loaded a warning is shown and the ROM starts with cleared flags
+func (emu *Emulator) loadFlags() {
+	emu.flagsFile = config.FlagsFile(movie.RomHash(emu.rom))
+	flags, err := config.LoadFlags(emu.flagsFile)
+	if err != nil {
+		emu.display.DisplayNotification(fmt.Sprintf("Flags not loaded: %v", err))
+	}
+	emu.flags = flags
+}
+
+// saveFlags saves the flag registers when the ROM changed them, changes during movies aren't saved
+func (emu *Emulator) saveFlags() {
+	if emu.cpu.RPL == emu.lastFlags {
+		return
+	}
+	emu.lastFlags = emu.cpu.RPL
+	if emu.movieActive() || emu.flagsFile == "" {
+		return
+	}
+
+	if err := config.SaveFlags(emu.flagsFile, emu.cpu.RPL); err != nil {
+		emu.showError(err)
+		return
+	}
+	emu.flags = emu.cpu.RPL
+}
+
+// clearFlags clears the saved flag registers of the loaded ROM
+func (emu *Emulator) clearFlags() error {
+	emu.flags = [16]byte{}
+	if emu.flagsFile == "" {
+		return nil
+	}
+	return config.ClearFlags(emu.flagsFile)
+}
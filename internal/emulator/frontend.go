@@ -0,0 +1,96 @@
+package emulator
+
+import (
+	"time"
+
+	"github.com/philw07/pich8-go/internal/config"
+	"github.com/philw07/pich8-go/internal/input"
+	"github.com/philw07/pich8-go/internal/palette"
+	"github.com/philw07/pich8-go/internal/sound"
+	"github.com/philw07/pich8-go/internal/videomemory"
+)
+
+// Frontend connects the emulator to the user, the window of the emulator implements the display and the input
+type Frontend struct {
+	Display Display
+	Input   Input
+	Audio   Audio
+}
+
+// Frame is the state shown by the display after every emulated frame
+type Frame struct {
+	Vmem    videomemory.VideoMemory
+	Palette palette.Palette
+	Stats   *PerfStats
+	Paused  bool
+	// Ticks is the number of emulated frames (timer ticks) so far, effects spanning several frames advance with it
+	Ticks uint64
+	// Audio describes the audio settings
+	Audio  string
+	Keypad KeypadState
+}
+
+// KeypadState is the state shown by the virtual keypad
+type KeypadState struct {
+	Pressed [16]bool
+	Tested  [16]bool
+}
+
+// Display shows the frames of the emulator and messages to the user
+type Display interface {
+	// Draw shows the given frame, it's called once per iteration of the main loop
+	Draw(frame *Frame)
+	// DisplayNotification shows the given text for a short time
+	DisplayNotification(text string)
+	// SetTitle shows the title of the loaded ROM, an empty title removes it
+	SetTitle(title string)
+	// Reset is called when a ROM is loaded, it discards the state carried over between the frames
+	Reset()
+	// PerformAction performs the commands which only concern the display, like toggling fullscreen.
+	// It's called for all commands the emulator doesn't perform itself.
+	PerformAction(action input.Action)
+	// UpdateSettings stores the display settings which may have been changed by commands
+	UpdateSettings(settings *config.Settings)
+	// OpenFile asks the user for a file to open, returns false if the user cancelled
+	OpenFile(title, dir string) (string, bool)
+	// ShowError informs the user about the given error
+	ShowError(err error)
+	// Closed returns whether the user closed the display, which ends the main loop
+	Closed() bool
+}
+
+// KeyEvent is a press or release of a key at the time the input received it
+type KeyEvent struct {
+	Key     string
+	Pressed bool
+	Time    time.Time
+}
+
+// Input reports the keys pressed by the user, the keys are named like in the key bindings
+type Input interface {
+	// KnownKey returns whether the given key name can be bound
+	KnownKey(key string) bool
+	// KeyEvents returns the presses and releases since the previous call in chronological order,
+	// the CHIP-8 keys change at the times of the events, also if they're shorter than a frame
+	KeyEvents() []KeyEvent
+	// JustPressed returns whether the key of the given binding was pressed with its modifier since the previous frame
+	JustPressed(binding input.Binding) bool
+	// KeypadKey returns the CHIP-8 key held down on the virtual keypad
+	KeypadKey() (byte, bool)
+}
+
+// Audio plays the sound of the emulated machine, it's implemented by sound.AudioPlayer
+type Audio interface {
+	SetSoundTimer(st byte)
+	PlayBuffer(buffer [16]byte)
+	QueueLatency() time.Duration
+	Volume() float64
+	SetVolume(volume float64)
+	Muted() bool
+	SetMute(mute bool)
+	Waveform() sound.Waveform
+	SetWaveform(waveform sound.Waveform)
+	Frequency() float64
+	SetFrequency(frequency float64)
+	Close() error
+}
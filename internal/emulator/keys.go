@@ -0,0 +1,48 @@
+package emulator
+
+import (
+	"fmt"
+
+	"github.com/philw07/pich8-go/internal/input"
+)
+
+// checkBindings returns an error if any of the given bindings uses a key unknown to the input
+func checkBindings(bindings *input.Bindings, in Input) error {
+	all := [][]input.Binding{}
+	all = append(all, bindings.Keypad[:]...)
+	for _, keys := range bindings.Commands {
+		all = append(all, keys)
+	}
+	for _, macro := range bindings.Macros {
+		all = append(all, macro.Keys)
+	}
+
+	for _, keys := range all {
+		for _, binding := range keys {
+			if !in.KnownKey(binding.Key) {
+				return fmt.Errorf("unknown key %q", binding.Key)
+			}
+		}
+	}
+	return nil
+}
+
+// anyHeld returns whether the key of any of the given bindings is held down, the modifier is ignored
+func anyHeld(held map[string]bool, bindings []input.Binding) bool {
+	for _, binding := range bindings {
+		if held[binding.Key] {
+			return true
+		}
+	}
+	return false
+}
+
+// justPressed returns whether any of the given bindings was just pressed
+func justPressed(in Input, bindings []input.Binding) bool {
+	for _, binding := range bindings {
+		if in.JustPressed(binding) {
+			return true
+		}
+	}
+	return false
+}
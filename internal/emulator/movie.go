@@ -0,0 +1,164 @@
+package emulator
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/philw07/pich8-go/internal/movie"
+	"github.com/philw07/pich8-go/internal/video"
+)
+
+// StartRecording resets the emulator and records all key state changes until StopRecording is called
+func (emu *Emulator) StartRecording(path string) error {
+	emu.stopMovie()
+	if err := emu.reset(); err != nil {
+		return err
+	}
+
+	// Movies start with cleared flags, so they don't depend on the saved ones
+	emu.cpu.RPL = [16]byte{}
+	emu.lastFlags = emu.cpu.RPL
+
+	seed := time.Now().UnixNano()
+	if emu.seeded {
+		seed = emu.seed
+	}
+	emu.cpu.Seed(seed)
+	emu.recording = &movie.Movie{
+		RomHash: movie.RomHash(emu.rom),
+		Quirks:  emu.cpu.Quirks(),
+		Seed:    seed,
+		Speed:   emu.getCPUSpeed(),
+	}
+	emu.recordingFile = path
+	emu.startMovieFrames()
+	emu.display.DisplayNotification("Recording movie")
+	return nil
+}
+
+// StopRecording stops the recording and saves the movie
+func (emu *Emulator) StopRecording() error {
+	if emu.recording == nil {
+		return nil
+	}
+
+	emu.recording.EndFrame = emu.frame
+	emu.recording.FinalHash = movie.FramebufferHash(emu.cpu.Vmem())
+	err := emu.recording.Save(emu.recordingFile)
+	if err == nil {
+		emu.display.DisplayNotification(fmt.Sprintf("Movie saved to %v", emu.recordingFile))
+	}
+	emu.recording = nil
+	return err
+}
+
+// PlayMovie resets the emulator and replays the given movie, the loaded ROM must match the movie
+func (emu *Emulator) PlayMovie(m *movie.Movie) error {
+	emu.stopMovie()
+	c, err := m.NewCPU(emu.rom)
+	if err != nil {
+		return err
+	}
+
+	emu.cpu = *c
+	emu.lastFlags = c.RPL
+	emu.playback = m
+	emu.player = movie.NewPlayer(m)
+	emu.startMovieFrames()
+	emu.display.DisplayNotification("Playing movie")
+	return nil
+}
+
+func (emu *Emulator) movieActive() bool {
+	return emu.recording != nil || emu.playback != nil
+}
+
+func (emu *Emulator) stopMovie() {
+	if emu.recording != nil {
+		if err := emu.StopRecording(); err != nil {
+			emu.showError(err)
+		}
+	}
+	emu.playback = nil
+	emu.player = nil
+}
+
+func (emu *Emulator) toggleRecording() {
+	if emu.recording != nil {
+		if err := emu.StopRecording(); err != nil {
+			emu.showError(err)
+		}
+		return
+	}
+
+	name := strings.TrimSuffix(emu.romName, filepath.Ext(emu.romName))
+	path := video.UniquePath(fmt.Sprintf("%v-%v.movie", name, time.Now().Format("20060102-150405")))
+	if err := emu.StartRecording(path); err != nil {
+		emu.showError(err)
+	}
+}
+
+func (emu *Emulator) startMovieFrames() {
+	emu.frame = 0
+	emu.frameStart = time.Now()
+}
+
+// performMovieFrames emulates the frames which are due in lockstep,
+// every frame executes a fixed number of instructions followed by one timer tick
+func (emu *Emulator) performMovieFrames() {
+	// Key events are applied per frame
+	for {
+		if _, ok := emu.keyEvents.Next(time.Now()); !ok {
+			break
+		}
+	}
+
+	speed := emu.getCPUSpeed()
+	if emu.playback != nil {
+		speed = emu.playback.Speed
+	}
+
+	due := int(time.Since(emu.frameStart) / (time.Second / timerFrequency))
+	for ; emu.frame < due; emu.frame++ {
+		keys := emu.keyEvents.State()
+		if emu.player != nil {
+			if emu.player.Done(emu.frame) {
+				emu.finishPlayback()
+				return
+			}
+			keys = emu.player.Keys(emu.frame)
+		} else {
+			emu.recording.Record(emu.frame, keys)
+		}
+
+		// Same as movie.RunFrame, the timers are ticked along with the audio and the capture
+		for i := 0; i < speed/timerFrequency; i++ {
+			emu.cpu.Tick(keys)
+		}
+		emu.tickTimers()
+		emu.stats.AddInstructions(speed / timerFrequency)
+		emu.stats.AddTimerTicks(1)
+		emu.updateSound()
+	}
+}
+
+func (emu *Emulator) finishPlayback() {
+	hash := movie.FramebufferHash(emu.cpu.Vmem())
+	result := "Movie finished, framebuffer matches"
+	if hash != emu.playback.FinalHash {
+		result = fmt.Sprintf("Movie finished, framebuffer MISMATCH (expected %v, got %v)", emu.playback.FinalHash, hash)
+	}
+	emu.display.DisplayNotification(result)
+
+	emu.playback = nil
+	emu.player = nil
+
+	// Continue with the realtime scheduler
+	emu.lastCycle = time.Now()
+	emu.lastTimer = time.Now()
+	for key, pressed := range emu.keyEvents.State() {
+		emu.cpu.SetKey(byte(key), pressed)
+	}
+}
@@ -0,0 +1,100 @@
+package emulator
+
+import "time"
+
+// FrameTimeBuckets are the upper bounds of the frame time histogram buckets,
+// frames slower than the last bound are counted in an additional bucket
+var FrameTimeBuckets = [...]time.Duration{
+	2 * time.Millisecond,
+	4 * time.Millisecond,
+	8 * time.Millisecond,
+	time.Second / 60,
+	time.Second / 30,
+}
+
+// PerfStats collects performance metrics of the emulation and publishes them once per second
+type PerfStats struct {
+	start     time.Time
+	lastFrame time.Time
+
+	instructions    int
+	timerTicks      int
+	correctedCycles int
+	correctedTicks  int
+	frameTimes      [len(FrameTimeBuckets) + 1]int
+
+	InstructionsPerSecond float64
+	TimerTicksPerSecond   float64
+	CorrectedCycles       int
+	CorrectedTimerTicks   int
+	FrameTimes            [len(FrameTimeBuckets) + 1]int
+	SpeedRatio            float64
+	AudioQueueLatency     time.Duration
+}
+
+// NewPerfStats creates and initializes a new instance
+func NewPerfStats() *PerfStats {
+	now := time.Now()
+	return &PerfStats{
+		start:     now,
+		lastFrame: now,
+	}
+}
+
+// AddInstructions needs to be called with the number of executed instructions
+func (ps *PerfStats) AddInstructions(n int) {
+	ps.instructions += n
+}
+
+// AddTimerTicks needs to be called with the number of performed timer ticks
+func (ps *PerfStats) AddTimerTicks(n int) {
+	ps.timerTicks += n
+}
+
+// AddCorrection needs to be called with the number of cycles and timer ticks added by the scheduler
+func (ps *PerfStats) AddCorrection(cycles, ticks int) {
+	ps.correctedCycles += cycles
+	ps.correctedTicks += ticks
+}
+
+// Frame needs to be called every time a frame is drawn, the target speed is used to calculate the speed ratio
+func (ps *PerfStats) Frame(targetSpeed int, audioQueueLatency time.Duration) {
+	ps.frameAt(time.Now(), targetSpeed, audioQueueLatency)
+}
+
+// frameAt counts a frame drawn at the given time
+func (ps *PerfStats) frameAt(now time.Time, targetSpeed int, audioQueueLatency time.Duration) {
+	frameTime := now.Sub(ps.lastFrame)
+	ps.lastFrame = now
+
+	bucket := len(FrameTimeBuckets)
+	for i, bound := range FrameTimeBuckets {
+		if frameTime < bound {
+			bucket = i
+			break
+		}
+	}
+	ps.frameTimes[bucket]++
+	ps.AudioQueueLatency = audioQueueLatency
+
+	// Publish values every second
+	elapsed := now.Sub(ps.start).Seconds()
+	if elapsed >= 1 {
+		ps.InstructionsPerSecond = float64(ps.instructions) / elapsed
+		ps.TimerTicksPerSecond = float64(ps.timerTicks) / elapsed
+		ps.CorrectedCycles = ps.correctedCycles
+		ps.CorrectedTimerTicks = ps.correctedTicks
+		ps.FrameTimes = ps.frameTimes
+		ps.SpeedRatio = 0
+		if targetSpeed > 0 {
+			ps.SpeedRatio = ps.InstructionsPerSecond / float64(targetSpeed)
+		}
+
+		ps.start = now
+		ps.instructions = 0
+		ps.timerTicks = 0
+		ps.correctedCycles = 0
+		ps.correctedTicks = 0
+		ps.frameTimes = [len(FrameTimeBuckets) + 1]int{}
+	}
+}
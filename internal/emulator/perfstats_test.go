@@ -0,0 +1,91 @@
+package emulator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPerfStats(t *testing.T) {
+	start := time.Now()
+
+	tests := []struct {
+		name         string
+		frameTime    time.Duration
+		frames       int
+		instructions int
+		ticks        int
+		targetSpeed  int
+
+		ips        float64
+		tps        float64
+		speedRatio float64
+		bucket     int
+	}{
+		// A frame time of exactly a bound falls into the next bucket
+		{"60 fps", time.Second / 60, 61, 12, 1, 720, 720, 60, 1, 4},
+		{"fast frames", time.Millisecond, 1000, 1, 0, 2000, 1000, 0, 0.5, 0},
+		{"333 fps", 3 * time.Millisecond, 334, 3, 1, 1000, 1000, 333.33, 1, 1},
+		{"slow frames", 100 * time.Millisecond, 10, 100, 6, 500, 1000, 60, 2, 5},
+		{"no target speed", 5 * time.Millisecond, 200, 5, 0, 0, 1000, 0, 0, 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			ps := PerfStats{start: start, lastFrame: start}
+			now := start
+			for i := 0; i < test.frames; i++ {
+				// Nothing is published before a second has passed
+				assert.Zero(ps.InstructionsPerSecond)
+
+				ps.AddInstructions(test.instructions)
+				ps.AddTimerTicks(test.ticks)
+				now = now.Add(test.frameTime)
+				ps.frameAt(now, test.targetSpeed, time.Millisecond)
+			}
+
+			elapsed := now.Sub(start).Seconds()
+			assert.InDelta(test.ips, ps.InstructionsPerSecond, test.ips*0.001+0.001)
+			assert.InDelta(test.tps, ps.TimerTicksPerSecond, test.tps*0.001+0.001)
+			assert.InDelta(test.speedRatio, ps.SpeedRatio, 0.001)
+			assert.InDelta(float64(test.instructions*test.frames)/elapsed, ps.InstructionsPerSecond, 0.001)
+			assert.Equal(time.Millisecond, ps.AudioQueueLatency)
+
+			var buckets [len(FrameTimeBuckets) + 1]int
+			buckets[test.bucket] = test.frames
+			assert.Equal(buckets, ps.FrameTimes)
+		})
+	}
+}
+
+func TestPerfStatsPublish(t *testing.T) {
+	assert := assert.New(t)
+
+	start := time.Now()
+	ps := PerfStats{start: start, lastFrame: start}
+	ps.AddInstructions(600)
+	ps.AddCorrection(50, 2)
+	ps.frameAt(start.Add(500*time.Millisecond), 600, 0)
+	assert.Zero(ps.CorrectedCycles)
+
+	// The values of the past second are published and the counting starts over
+	ps.AddInstructions(600)
+	ps.AddCorrection(10, 1)
+	ps.frameAt(start.Add(time.Second), 600, 0)
+	assert.Equal(1200.0, ps.InstructionsPerSecond)
+	assert.Equal(2.0, ps.SpeedRatio)
+	assert.Equal(60, ps.CorrectedCycles)
+	assert.Equal(3, ps.CorrectedTimerTicks)
+	// Both frames were slower than the last bound
+	assert.Equal([len(FrameTimeBuckets) + 1]int{5: 2}, ps.FrameTimes)
+
+	ps.AddInstructions(300)
+	ps.frameAt(start.Add(2*time.Second), 600, 0)
+	assert.Equal(300.0, ps.InstructionsPerSecond)
+	assert.Equal(0.5, ps.SpeedRatio)
+	assert.Zero(ps.CorrectedCycles)
+	assert.Equal([len(FrameTimeBuckets) + 1]int{5: 1}, ps.FrameTimes)
+}
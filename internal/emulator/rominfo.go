@@ -0,0 +1,137 @@
+package emulator
+
+import (
+	"fmt"
+
+	"github.com/philw07/pich8-go/internal/cpu"
+	"github.com/philw07/pich8-go/internal/detect"
+	"github.com/philw07/pich8-go/internal/input"
+	"github.com/philw07/pich8-go/internal/movie"
+	"github.com/philw07/pich8-go/internal/palette"
+	"github.com/philw07/pich8-go/internal/romdb"
+)
+
+// romControlKeys maps the game controls of the ROM database to the keys bound in addition to the keypad
+var romControlKeys = map[string]string{
+	"up":    "Up",
+	"down":  "Down",
+	"left":  "Left",
+	"right": "Right",
+	"a":     "Space",
+	"b":     "Enter",
+}
+
+// minDetectionConfidence is the confidence above which the detected quirks of unknown ROMs are applied
+const minDetectionConfidence = 0.75
+
+// romSettings are the settings which are replaced by the ROM database
+type romSettings struct {
+	speed   int
+	quirks  cpu.Quirks
+	palette palette.Palette
+}
+
+// ExplicitSettings marks the settings given on the command line, which aren't replaced by the ROM database.
+// Explicit quirks also aren't replaced by detected ones.
+type ExplicitSettings struct {
+	Speed   bool
+	Quirks  bool
+	Palette bool
+}
+
+// SetDatabase sets the ROM database which is used to apply the settings of known ROMs on loading
+func (emu *Emulator) SetDatabase(db *romdb.Database) {
+	emu.database = db
+}
+
+// SetDetection enables or disables the quirk detection for ROMs which aren't in the database,
+// it's enabled by default
+func (emu *Emulator) SetDetection(enabled bool) {
+	emu.noDetection = !enabled
+}
+
+// SetExplicit sets the settings which are kept when a ROM is loaded
+func (emu *Emulator) SetExplicit(explicit ExplicitSettings) {
+	emu.explicit = explicit
+}
+
+// SetThemes sets the palette themes which are switched through with the palette command
+func (emu *Emulator) SetThemes(themes []palette.Theme) {
+	emu.themes = themes
+}
+
+// nextTheme switches to the palette theme after the current one
+func (emu *Emulator) nextTheme() {
+	if len(emu.themes) == 0 {
+		return
+	}
+	next := 0
+	for i, theme := range emu.themes {
+		if theme.Palette == emu.palette {
+			next = (i + 1) % len(emu.themes)
+			break
+		}
+	}
+	emu.palette = emu.themes[next].Palette
+	emu.display.DisplayNotification(fmt.Sprintf("Palette: %v", emu.themes[next].Name))
+}
+
+// applyRomInfo looks up the loaded ROM in the database and applies its settings.
+// For unknown ROMs the quirks are detected if enabled, if the detection isn't confident the previous settings are restored.
+func (emu *Emulator) applyRomInfo() {
+	if !emu.romSpecific {
+		emu.defaults = romSettings{speed: emu.cpuSpeed, quirks: emu.quirks, palette: emu.palette}
+	}
+	emu.romSpecific = false
+	emu.romInfo = nil
+	emu.romKeypad = [16][]input.Binding{}
+	emu.cpuSpeed = emu.defaults.speed
+	emu.quirks = emu.defaults.quirks
+	emu.palette = emu.defaults.palette
+	emu.display.SetTitle("")
+
+	var entry *romdb.Entry
+	ok := false
+	if emu.database != nil {
+		entry, ok = emu.database.Lookup(movie.RomHash(emu.rom))
+	}
+	if !ok {
+		if !emu.noDetection && !emu.explicit.Quirks {
+			emu.detectQuirks()
+		}
+		return
+	}
+
+	emu.romInfo = entry
+	emu.romSpecific = true
+	if !emu.explicit.Quirks {
+		emu.quirks = entry.CPUQuirks()
+	}
+	if speed := entry.Speed(); speed > 0 && !emu.explicit.Speed {
+		emu.cpuSpeed = speed
+	}
+	if p, err := entry.Palette(emu.themes, emu.defaults.palette); err == nil && !emu.explicit.Palette {
+		emu.palette = p
+	}
+	for control, key := range entry.Keys {
+		if name, ok := romControlKeys[control]; ok && key >= 0 && key < len(emu.romKeypad) {
+			emu.romKeypad[key] = append(emu.romKeypad[key], input.Binding{Key: name})
+		}
+	}
+
+	emu.display.SetTitle(entry.Title)
+	emu.display.DisplayNotification(fmt.Sprintf("%v (%v, %vHz)", entry, cpu.ProfileName(emu.quirks), emu.cpuSpeed))
+}
+
+// detectQuirks analyzes the loaded ROM and applies the detected quirks if the detection is confident
+func (emu *Emulator) detectQuirks() {
+	res := detect.Analyze(emu.rom)
+	if res.Confidence < minDetectionConfidence {
+		emu.display.DisplayNotification(fmt.Sprintf("Unknown ROM, maybe %v", res))
+		return
+	}
+
+	emu.quirks = res.Quirks
+	emu.romSpecific = true
+	emu.display.DisplayNotification(fmt.Sprintf("Detected %v", res))
+}
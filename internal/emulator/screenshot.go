@@ -0,0 +1,44 @@
+package emulator
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/philw07/pich8-go/internal/video"
+)
+
+// SaveScreenshot writes the current frame with the active palette to the given PNG file,
+// every CHIP-8 pixel is enlarged to a square of the given size
+func (emu *Emulator) SaveScreenshot(path string, scale int) error {
+	vmem := emu.cpu.Vmem()
+	frame := emu.palette.Render(&vmem, nil)
+	return video.SavePNG(path, video.Resize(frame, vmem.RenderWidth()/vmem.Width(), scale))
+}
+
+// Screenshot saves a screenshot named after the ROM and the current time to the screenshot directory
+// in the scale of the settings and returns its path
+func (emu *Emulator) Screenshot() (string, error) {
+	path := video.UniquePath(filepath.Join(emu.settings.ScreenshotDir(), video.ScreenshotName(emu.romTitle(), time.Now())))
+	if err := emu.SaveScreenshot(path, emu.settings.ScreenshotScale); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// romTitle returns the title of the ROM from the database or its file name
+func (emu *Emulator) romTitle() string {
+	if emu.romInfo != nil && emu.romInfo.Title != "" {
+		return emu.romInfo.Title
+	}
+	return strings.TrimSuffix(emu.romName, filepath.Ext(emu.romName))
+}
+
+func (emu *Emulator) takeScreenshot() {
+	path, err := emu.Screenshot()
+	if err != nil {
+		emu.showError(err)
+		return
+	}
+	emu.display.DisplayNotification("Screenshot " + filepath.Base(path))
+}
@@ -0,0 +1,349 @@
+// Package gui implements the frontend of the emulator with a pixelgl window and native dialogs
+package gui
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/png"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+	"github.com/philw07/pich8-go/internal/config"
+	"github.com/philw07/pich8-go/internal/data"
+	"github.com/philw07/pich8-go/internal/emulator"
+	"github.com/philw07/pich8-go/internal/input"
+	"github.com/philw07/pich8-go/internal/palette"
+	"github.com/philw07/pich8-go/internal/video"
+	"github.com/sqweek/dialog"
+	"golang.org/x/image/font/basicfont"
+)
+
+const (
+	windowTitle = "pich8-go"
+
+	c8Width  = 64
+	c8Height = 32
+
+	textMargin = 5
+)
+
+// Display is the window of the emulator, it implements the emulator.Display and the emulator.Input
+type Display struct {
+	window               *pixelgl.Window
+	fpsCounter           FpsCounter
+	hudText              *text.Text
+	lastNotificationTime time.Time
+	notificationText     *text.Text
+	displayHud           bool
+	displayKeypad        bool
+	dimUntestedKeys      bool
+	keypadText           *text.Text
+	displayInstructions  bool
+	instructionsText     *text.Text
+	imd                  *imdraw.IMDraw
+	scaleMode            video.ScaleMode
+	border               palette.Color
+	postProcessor        video.PostProcessor
+	lastTicks            uint64
+	filters              video.Filters
+	frame                *image.RGBA
+	keyEvents            []emulator.KeyEvent
+
+	// Window geometry before switching to fullscreen
+	windowed config.Window
+}
+
+// NewDisplay creates and initializes a new Display instance showing the given instructions,
+// the window geometry, VSync, fullscreen and the scaling are taken from the given settings
+func NewDisplay(instructions []string, settings *config.Settings) (*Display, error) {
+	geometry := settings.Window
+	if geometry.Width <= 0 || geometry.Height <= 0 {
+		geometry = centeredWindow(10*c8Width, 10*c8Height)
+	}
+
+	cfg := pixelgl.WindowConfig{
+		Title:     windowTitle,
+		Bounds:    pixel.R(0, 0, geometry.Width, geometry.Height),
+		Position:  pixel.V(geometry.X, geometry.Y),
+		VSync:     settings.VSync,
+		Resizable: true,
+	}
+	if settings.Fullscreen {
+		cfg.Monitor = pixelgl.PrimaryMonitor()
+	}
+
+	// Get icon
+	iconImage, _, err := image.Decode(bytes.NewReader(data.Icon[:]))
+	if err == nil {
+		icon := pixel.PictureDataFromImage(iconImage)
+		cfg.Icon = []pixel.Picture{icon}
+	}
+
+	win, err := pixelgl.NewWindow(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	textAtlas := text.NewAtlas(basicfont.Face7x13, text.ASCII)
+	instuctionsText := text.New(pixel.ZV, textAtlas)
+	for _, line := range instructions {
+		fmt.Fprintln(instuctionsText, line)
+	}
+
+	disp := &Display{
+		window:              win,
+		scaleMode:           settings.Scaling,
+		border:              settings.Border,
+		postProcessor:       video.PostProcessor{Scaler: settings.Scaler, Filters: settings.Filters},
+		filters:             settings.Filters,
+		windowed:            geometry,
+		hudText:             text.New(pixel.ZV, textAtlas),
+		keypadText:          text.New(pixel.ZV, textAtlas),
+		notificationText:    text.New(pixel.V(0, textMargin), textAtlas),
+		displayInstructions: true,
+		instructionsText:    instuctionsText,
+		imd:                 imdraw.New(nil),
+	}
+	disp.watchKeys()
+	return disp, nil
+}
+
+// SetScale resizes the window to the given multiple of the CHIP-8 resolution and centers it
+func (disp *Display) SetScale(scale float64) {
+	geometry := centeredWindow(scale*c8Width, scale*c8Height)
+	if disp.fullscreen() {
+		disp.windowed = geometry
+		return
+	}
+	disp.window.SetBounds(pixel.R(0, 0, geometry.Width, geometry.Height))
+	disp.window.SetPos(pixel.V(geometry.X, geometry.Y))
+}
+
+func centeredWindow(width, height float64) config.Window {
+	monitorWidth, monitorHeight := pixelgl.PrimaryMonitor().Size()
+	return config.Window{
+		X:      monitorWidth/2 - width/2,
+		Y:      monitorHeight/2 - height/2,
+		Width:  width,
+		Height: height,
+	}
+}
+
+// toggleFullscreen toggles between fullscreen and windowed
+func (disp *Display) toggleFullscreen() {
+	if disp.window.Monitor() == nil {
+		disp.windowed = disp.windowGeometry()
+		disp.window.SetMonitor(pixelgl.PrimaryMonitor())
+	} else {
+		disp.window.SetMonitor(nil)
+	}
+}
+
+// fullscreen returns whether the window is fullscreen
+func (disp *Display) fullscreen() bool {
+	return disp.window.Monitor() != nil
+}
+
+// windowGeometry returns the position and size of the window in windowed mode
+func (disp *Display) windowGeometry() config.Window {
+	if disp.fullscreen() {
+		return disp.windowed
+	}
+	pos := disp.window.GetPos()
+	bounds := disp.window.Bounds()
+	return config.Window{X: pos.X, Y: pos.Y, Width: bounds.W(), Height: bounds.H()}
+}
+
+// toggleFilters switches the post-processing filters off and on again, returns whether they're enabled.
+// If no filters are configured, the default filters are switched on.
+func (disp *Display) toggleFilters() bool {
+	if disp.postProcessor.Filters.Enabled() {
+		disp.postProcessor.Filters = video.Filters{}
+		return false
+	}
+	disp.postProcessor.Filters = disp.filters
+	if !disp.filters.Enabled() {
+		disp.postProcessor.Filters = video.DefaultFilters
+	}
+	return true
+}
+
+// UpdateSettings stores the scaling, the configured filters and the window state to the given settings
+func (disp *Display) UpdateSettings(settings *config.Settings) {
+	settings.Scaling = disp.scaleMode
+	settings.Scaler = disp.postProcessor.Scaler
+	settings.Filters = disp.filters
+	settings.VSync = disp.window.VSync()
+	settings.Fullscreen = disp.fullscreen()
+	settings.Window = disp.windowGeometry()
+}
+
+// PerformAction performs the commands concerning the window and its overlays
+func (disp *Display) PerformAction(action input.Action) {
+	switch action {
+	case input.Instructions:
+		disp.displayInstructions = !disp.displayInstructions
+	case input.Hud:
+		disp.displayHud = !disp.displayHud
+	case input.Keypad:
+		disp.displayKeypad = !disp.displayKeypad
+	case input.KeypadDim:
+		disp.dimUntestedKeys = !disp.dimUntestedKeys
+		disp.DisplayNotification(toggleText("Dim untested keys", disp.dimUntestedKeys))
+	case input.ScaleMode:
+		disp.scaleMode = (disp.scaleMode + 1) % video.ScaleMode(len(video.ScaleModes))
+		disp.DisplayNotification(fmt.Sprintf("Scale mode: %v", disp.scaleMode))
+	case input.Scaler:
+		pp := &disp.postProcessor
+		pp.Scaler = (pp.Scaler + 1) % video.Scaler(len(video.Scalers))
+		disp.DisplayNotification(fmt.Sprintf("Scaler: %v", pp.Scaler))
+	case input.Filters:
+		disp.DisplayNotification(toggleText("Filters", disp.toggleFilters()))
+	case input.VSync:
+		disp.window.SetVSync(!disp.window.VSync())
+	case input.Fullscreen:
+		disp.toggleFullscreen()
+	}
+}
+
+func toggleText(name string, active bool) string {
+	if active {
+		return fmt.Sprintf("%v ON", name)
+	}
+
+	return fmt.Sprintf("%v OFF", name)
+}
+
+// Reset hides the instructions and discards the frames kept by the phosphor persistence
+func (disp *Display) Reset() {
+	disp.displayInstructions = false
+	disp.postProcessor.Reset()
+}
+
+// SetTitle shows the given ROM title in the window title
+func (disp *Display) SetTitle(title string) {
+	if title == "" {
+		disp.window.SetTitle(windowTitle)
+	} else {
+		disp.window.SetTitle(fmt.Sprintf("%v - %v", windowTitle, title))
+	}
+}
+
+// OpenFile shows a file dialog starting in the given directory
+func (disp *Display) OpenFile(title, dir string) (string, bool) {
+	file, err := dialog.File().Title(title).SetStartDir(dir).Load()
+	return file, err == nil
+}
+
+// ShowError shows the given error in a message box
+func (disp *Display) ShowError(err error) {
+	dialog.Message(fmt.Sprintf("Error occurred: %v", err)).Title("Error").Error()
+}
+
+// Closed returns whether the window was closed
+func (disp *Display) Closed() bool {
+	return disp.window.Closed()
+}
+
+// DisplayNotification displays the given text for a short time
+func (disp *Display) DisplayNotification(text string) {
+	disp.lastNotificationTime = time.Now()
+	disp.notificationText.Clear()
+	fmt.Fprint(disp.notificationText, text)
+}
+
+// Draw draws the given frame and the overlays to the window
+func (disp *Display) Draw(frame *emulator.Frame) {
+	w := disp.window.Bounds().W()
+	vmem := &frame.Vmem
+
+	disp.window.Clear(disp.border)
+
+	// Draw
+	disp.frame = frame.Palette.Render(vmem, disp.frame)
+	img := disp.postProcessor.Process(disp.frame, vmem.RenderWidth()/vmem.Width(), int(frame.Ticks-disp.lastTicks))
+	disp.lastTicks = frame.Ticks
+	pic := pixel.PictureDataFromImage(img)
+	sprite := pixel.NewSprite(pic, pic.Bounds())
+	rect := video.Fit(disp.scaleMode, vmem.RenderWidth(), vmem.RenderHeight(), w, disp.window.Bounds().H())
+	center := pixel.V(rect.X+rect.W/2, rect.Y+rect.H/2)
+	mat := pixel.IM
+	mat = mat.Moved(center)
+	mat = mat.ScaledXY(center, pixel.V(rect.W/pic.Bounds().W(), rect.H/pic.Bounds().H()))
+	sprite.Draw(disp.window, mat)
+
+	// Update fps and draw HUD
+	fps := disp.fpsCounter.Tick()
+	if disp.displayHud {
+		disp.updateHud(fps, frame.Stats, frame.Audio)
+		disp.drawText(disp.hudText, pixel.V(textMargin, -disp.hudText.Dot.Y))
+	}
+
+	// Display virtual keypad
+	if disp.displayKeypad {
+		disp.drawKeypad(frame.Keypad)
+	}
+
+	// Display CPU speed
+	if time.Since(disp.lastNotificationTime).Seconds() <= 2 {
+		xPos := disp.window.Bounds().W() - disp.notificationText.Bounds().W()
+		disp.drawText(disp.notificationText, pixel.V(xPos, 0))
+	}
+
+	// Display instructions
+	if disp.displayInstructions {
+		x := math.Floor(w/2 - disp.instructionsText.Bounds().W()/2)
+		y := math.Floor(-disp.instructionsText.Dot.Y)
+		disp.drawText(disp.instructionsText, pixel.V(x, y))
+	}
+
+	disp.window.Update()
+}
+
+func (disp *Display) updateHud(fps float64, stats *emulator.PerfStats, audio string) {
+	disp.hudText.Clear()
+	fmt.Fprintf(disp.hudText, "FPS:          %v\n", int(fps))
+	fmt.Fprintf(disp.hudText, "Instructions: %v/s\n", int(stats.InstructionsPerSecond))
+	fmt.Fprintf(disp.hudText, "Timer ticks:  %.1f/s\n", stats.TimerTicksPerSecond)
+	fmt.Fprintf(disp.hudText, "Corrected:    +%v cycles, +%v ticks\n", stats.CorrectedCycles, stats.CorrectedTimerTicks)
+	fmt.Fprintf(disp.hudText, "Audio:        %v\n", audio)
+	fmt.Fprintf(disp.hudText, "Audio queue:  %v\n", stats.AudioQueueLatency.Round(time.Millisecond))
+	fmt.Fprintf(disp.hudText, "Speed ratio:  %.2fx\n", stats.SpeedRatio)
+	fmt.Fprintln(disp.hudText, "Frame times:")
+
+	total := 0
+	for _, count := range stats.FrameTimes {
+		total += count
+	}
+	for i, count := range stats.FrameTimes {
+		label := ">="
+		bound := emulator.FrameTimeBuckets[len(emulator.FrameTimeBuckets)-1]
+		if i < len(emulator.FrameTimeBuckets) {
+			label = "<"
+			bound = emulator.FrameTimeBuckets[i]
+		}
+		bar := 0
+		if total > 0 {
+			bar = count * 20 / total
+		}
+		fmt.Fprintf(disp.hudText, "  %2v%-5.1fms %-20v %v\n", label, bound.Seconds()*1000, strings.Repeat("#", bar), count)
+	}
+}
+
+func (disp *Display) drawText(text *text.Text, pos pixel.Vec) {
+	disp.imd.Clear()
+
+	disp.imd.Color = pixel.RGB(0.1, 0.1, 0.1).Mul(pixel.Alpha(0.85))
+	disp.imd.Push(text.Bounds().Min.Add(pos).Add(pixel.V(-textMargin, -textMargin)))
+	disp.imd.Push(text.Bounds().Max.Add(pos).Add(pixel.V(textMargin, textMargin)))
+	disp.imd.Rectangle(0)
+	disp.imd.Draw(disp.window)
+
+	text.Draw(disp.window, pixel.IM.Moved(pos))
+}
@@ -1,4 +1,4 @@
-package emulator
+package gui
 
 import "time"
 
@@ -0,0 +1,83 @@
+package gui
+
+import (
+	"fmt"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/philw07/pich8-go/internal/emulator"
+)
+
+const (
+	keypadCellSize = 36
+	keypadSpacing  = 4
+)
+
+// keypadLayout contains the CHIP-8 keys in the order of the hex keypad, top row first
+var keypadLayout = [4][4]byte{
+	{0x1, 0x2, 0x3, 0xC},
+	{0x4, 0x5, 0x6, 0xD},
+	{0x7, 0x8, 0x9, 0xE},
+	{0xA, 0x0, 0xB, 0xF},
+}
+
+// keypadRect returns the area of the given key in the top right corner of the window
+func (disp *Display) keypadRect(row, col int) pixel.Rect {
+	size := 4*keypadCellSize + 3*keypadSpacing
+	origin := pixel.V(disp.window.Bounds().W()-textMargin-float64(size), disp.window.Bounds().H()-textMargin)
+	min := origin.Add(pixel.V(float64(col*(keypadCellSize+keypadSpacing)), -float64((row+1)*keypadCellSize+row*keypadSpacing)))
+	return pixel.Rect{Min: min, Max: min.Add(pixel.V(keypadCellSize, keypadCellSize))}
+}
+
+// KeypadKey returns the CHIP-8 key of the virtual keypad held down with the left mouse button
+func (disp *Display) KeypadKey() (byte, bool) {
+	if !disp.displayKeypad || !disp.window.Pressed(pixelgl.MouseButtonLeft) {
+		return 0, false
+	}
+
+	pos := disp.window.MousePosition()
+	for row := range keypadLayout {
+		for col, key := range keypadLayout[row] {
+			if disp.keypadRect(row, col).Contains(pos) {
+				return key, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func (disp *Display) drawKeypad(state emulator.KeypadState) {
+	disp.imd.Clear()
+	for row := range keypadLayout {
+		for col, key := range keypadLayout[row] {
+			rect := disp.keypadRect(row, col)
+			switch {
+			case state.Pressed[key]:
+				disp.imd.Color = pixel.RGB(0.9, 0.9, 0.9).Mul(pixel.Alpha(0.9))
+			case disp.dimUntestedKeys && !state.Tested[key]:
+				disp.imd.Color = pixel.RGB(0.1, 0.1, 0.1).Mul(pixel.Alpha(0.35))
+			default:
+				disp.imd.Color = pixel.RGB(0.1, 0.1, 0.1).Mul(pixel.Alpha(0.85))
+			}
+			disp.imd.Push(rect.Min, rect.Max)
+			disp.imd.Rectangle(0)
+		}
+	}
+	disp.imd.Draw(disp.window)
+
+	for row := range keypadLayout {
+		for col, key := range keypadLayout[row] {
+			rect := disp.keypadRect(row, col)
+			disp.keypadText.Clear()
+			disp.keypadText.Color = pixel.RGB(1, 1, 1)
+			if state.Pressed[key] {
+				disp.keypadText.Color = pixel.RGB(0, 0, 0)
+			} else if disp.dimUntestedKeys && !state.Tested[key] {
+				disp.keypadText.Color = pixel.RGB(0.4, 0.4, 0.4)
+			}
+			fmt.Fprintf(disp.keypadText, "%X", key)
+			pos := rect.Center().Sub(disp.keypadText.Bounds().Center())
+			disp.keypadText.Draw(disp.window, pixel.IM.Moved(pos.Floor()))
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package gui
+
+import (
+	"time"
+
+	"github.com/faiface/mainthread"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/philw07/pich8-go/internal/emulator"
+	"github.com/philw07/pich8-go/internal/input"
+)
+
+// buttonsByName maps the key names to the window buttons
+var buttonsByName = func() map[string]pixelgl.Button {
+	buttons := map[string]pixelgl.Button{}
+	for b := pixelgl.Button(0); b <= pixelgl.KeyLast; b++ {
+		if name := b.String(); name != "Invalid" {
+			buttons[name] = b
+		}
+	}
+	return buttons
+}()
+
+// KnownKey returns whether the window has a button with the given name
+func (disp *Display) KnownKey(key string) bool {
+	_, ok := buttonsByName[key]
+	return ok
+}
+
+// watchKeys records the key presses and releases with the time they're received.
+// The window only keeps the key states of the last poll, so it would lose keys tapped between two frames,
+// therefore the key callback of the window is wrapped. The callback runs while the window polls its events.
+func (disp *Display) watchKeys() {
+	mainthread.Call(func() {
+		window := glfw.GetCurrentContext()
+		var previous glfw.KeyCallback
+		previous = window.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+			if key != glfw.KeyUnknown && action != glfw.Repeat {
+				event := emulator.KeyEvent{Key: pixelgl.Button(key).String(), Pressed: action == glfw.Press, Time: time.Now()}
+				disp.keyEvents = append(disp.keyEvents, event)
+			}
+			if previous != nil {
+				previous(w, key, scancode, action, mods)
+			}
+		})
+	})
+}
+
+// KeyEvents returns the key presses and releases since the previous call
+func (disp *Display) KeyEvents() []emulator.KeyEvent {
+	events := disp.keyEvents
+	disp.keyEvents = nil
+	return events
+}
+
+// JustPressed returns whether the key of the given binding was pressed since the previous frame,
+// Ctrl has to be held down exactly if the binding requires it
+func (disp *Display) JustPressed(binding input.Binding) bool {
+	ctrl := disp.window.Pressed(pixelgl.KeyLeftControl) || disp.window.Pressed(pixelgl.KeyRightControl)
+	button, ok := buttonsByName[binding.Key]
+	return ok && binding.Ctrl == ctrl && disp.window.JustPressed(button)
+}
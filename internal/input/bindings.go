@@ -0,0 +1,319 @@
+package input
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const ctrlPrefix = "Ctrl+"
+
+// Action is an emulator command which can be bound to keys
+type Action string
+
+const (
+	OpenRom           Action = "OpenRom"
+	SpeedUp           Action = "SpeedUp"
+	SpeedDown         Action = "SpeedDown"
+	Pause             Action = "Pause"
+	Mute              Action = "Mute"
+	VolumeUp          Action = "VolumeUp"
+	VolumeDown        Action = "VolumeDown"
+	Instructions      Action = "Instructions"
+	Hud               Action = "Hud"
+	VSync             Action = "VSync"
+	Waveform          Action = "Waveform"
+	Reset             Action = "Reset"
+	HardReset         Action = "HardReset"
+	Fullscreen        Action = "Fullscreen"
+	Quit              Action = "Quit"
+	QuirkLoadStore    Action = "QuirkLoadStore"
+	QuirkShift        Action = "QuirkShift"
+	QuirkJump         Action = "QuirkJump"
+	QuirkVfOrder      Action = "QuirkVfOrder"
+	QuirkDraw         Action = "QuirkDraw"
+	ToneFrequencyUp   Action = "ToneFrequencyUp"
+	ToneFrequencyDown Action = "ToneFrequencyDown"
+	RecordMovie       Action = "RecordMovie"
+	Keypad            Action = "Keypad"
+	KeypadDim         Action = "KeypadDim"
+	Autofire          Action = "Autofire"
+	Palette           Action = "Palette"
+	ScaleMode         Action = "ScaleMode"
+	Filters           Action = "Filters"
+	Scaler            Action = "Scaler"
+	Screenshot        Action = "Screenshot"
+	Capture           Action = "Capture"
+)
+
+// Actions contains all actions in the order they're listed in the instructions
+var Actions = [...]Action{
+	OpenRom, SpeedUp, SpeedDown, Pause, Mute, VolumeUp, VolumeDown,
+	Instructions, Hud, VSync, Palette, ScaleMode, Scaler, Filters, Screenshot, Capture, Waveform, Reset, HardReset, Keypad, KeypadDim, Autofire, RecordMovie, Fullscreen, Quit,
+	QuirkLoadStore, QuirkShift, QuirkJump, QuirkVfOrder, QuirkDraw,
+	ToneFrequencyUp, ToneFrequencyDown,
+}
+
+var actionDescriptions = map[Action]string{
+	OpenRom:           "Open ROM",
+	SpeedUp:           "Increase CPU Speed",
+	SpeedDown:         "Decrease CPU Speed",
+	Pause:             "Pause on/off",
+	Mute:              "Mute on/off",
+	VolumeUp:          "Increase volume",
+	VolumeDown:        "Decrease volume",
+	Instructions:      "Display these instructions",
+	Hud:               "Display performance HUD",
+	VSync:             "VSync on/off",
+	Waveform:          "Switch tone waveform",
+	Reset:             "Reset",
+	HardReset:         "Reset and clear the saved flags",
+	Fullscreen:        "Fullscreen",
+	Quit:              "Quit",
+	QuirkLoadStore:    "Load/store quirk on/off",
+	QuirkShift:        "Shift quirk on/off",
+	QuirkJump:         "Jump quirk on/off",
+	QuirkVfOrder:      "VF order quirk on/off",
+	QuirkDraw:         "Draw quirk on/off",
+	ToneFrequencyUp:   "Increase tone frequency",
+	ToneFrequencyDown: "Decrease tone frequency",
+	RecordMovie:       "Start/stop movie recording",
+	Keypad:            "Display virtual keypad",
+	KeypadDim:         "Dim keys not tested by the ROM",
+	Autofire:          "Autofire on/off",
+	Palette:           "Switch color palette",
+	ScaleMode:         "Switch scale mode",
+	Filters:           "Post-processing filters on/off",
+	Scaler:            "Switch pixel-art scaler",
+	Screenshot:        "Save screenshot",
+	Capture:           "Record video on/off",
+}
+
+// Description returns a human-readable description of the action
+func (a Action) Description() string {
+	return actionDescriptions[a]
+}
+
+// Binding is a key, optionally combined with the control modifier.
+// The key names match the names of the window library, e.g. "A", "F1" or "PageUp".
+type Binding struct {
+	Key  string
+	Ctrl bool
+}
+
+// ParseBinding parses a binding like "Ctrl+O" or "F1"
+func ParseBinding(s string) (Binding, error) {
+	b := Binding{Key: s}
+	if strings.HasPrefix(s, ctrlPrefix) {
+		b.Key = strings.TrimPrefix(s, ctrlPrefix)
+		b.Ctrl = true
+	}
+	if b.Key == "" {
+		return b, fmt.Errorf("invalid key binding %q", s)
+	}
+	return b, nil
+}
+
+func (b Binding) String() string {
+	if b.Ctrl {
+		return ctrlPrefix + b.Key
+	}
+	return b.Key
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (b Binding) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (b *Binding) UnmarshalText(text []byte) error {
+	parsed, err := ParseBinding(string(text))
+	if err != nil {
+		return err
+	}
+	*b = parsed
+	return nil
+}
+
+// Bindings maps the CHIP-8 keys and the emulator commands to keys
+type Bindings struct {
+	Keypad   [16][]Binding
+	Commands map[Action][]Binding
+
+	// Autofire rate in frames per CHIP-8 key
+	Autofire [16]int
+	Macros   []Macro
+}
+
+// bindingsFile is the layout of the key bindings file
+type bindingsFile struct {
+	Layout   string               `json:"layout,omitempty"`
+	Keypad   map[string][]Binding `json:"keypad,omitempty"`
+	Commands map[Action][]Binding `json:"commands,omitempty"`
+	Autofire map[string]int       `json:"autofire,omitempty"`
+	Macros   []Macro              `json:"macros,omitempty"`
+}
+
+var layouts = map[string][16][]string{
+	// Keys are mapped by position, which corresponds to the key names of the US layout
+	"default": {
+		{"X"}, {"1"}, {"2"}, {"3"},
+		{"Q"}, {"W"}, {"E"}, {"A"},
+		{"S"}, {"D"}, {"Z"}, {"C"},
+		{"4"}, {"R"}, {"F"}, {"V"},
+	},
+	// Digits on the numeric keypad with the same labels, A-F on the remaining keys
+	"numpad": {
+		{"KP0"}, {"KP1"}, {"KP2"}, {"KP3"},
+		{"KP4"}, {"KP5"}, {"KP6"}, {"KP7"},
+		{"KP8"}, {"KP9"}, {"KPDivide"}, {"KPMultiply"},
+		{"KPSubtract"}, {"KPAdd"}, {"KPEnter"}, {"KPDecimal"},
+	},
+	// Default layout with additional arrow keys for 2, 4, 6, 8 and space for 5
+	"arrows": {
+		{"X"}, {"1"}, {"2", "Up"}, {"3"},
+		{"Q", "Left"}, {"W", "Space"}, {"E", "Right"}, {"A"},
+		{"S", "Down"}, {"D"}, {"Z"}, {"C"},
+		{"4"}, {"R"}, {"F"}, {"V"},
+	},
+}
+
+var defaultCommands = map[Action][]string{
+	OpenRom:           {"Ctrl+O"},
+	SpeedUp:           {"PageUp"},
+	SpeedDown:         {"PageDown"},
+	Pause:             {"P"},
+	Mute:              {"M"},
+	VolumeUp:          {"Equal"},
+	VolumeDown:        {"Minus"},
+	Instructions:      {"F1"},
+	Hud:               {"F2"},
+	VSync:             {"F3"},
+	Waveform:          {"F4"},
+	Reset:             {"F5"},
+	HardReset:         {"Ctrl+F5"},
+	Fullscreen:        {"F11"},
+	Quit:              {"Escape"},
+	QuirkLoadStore:    {"Ctrl+1"},
+	QuirkShift:        {"Ctrl+2"},
+	QuirkJump:         {"Ctrl+3"},
+	QuirkVfOrder:      {"Ctrl+4"},
+	QuirkDraw:         {"Ctrl+5"},
+	ToneFrequencyUp:   {"Ctrl+PageUp"},
+	ToneFrequencyDown: {"Ctrl+PageDown"},
+	RecordMovie:       {"F9"},
+	Keypad:            {"F6"},
+	KeypadDim:         {"F7"},
+	Autofire:          {"F8"},
+	Palette:           {"F10"},
+	ScaleMode:         {"F12"},
+	Filters:           {"Ctrl+F12"},
+	Scaler:            {"Ctrl+F10"},
+	Screenshot:        {"Ctrl+S"},
+	Capture:           {"Ctrl+R"},
+}
+
+// Layouts returns the names of the built-in keypad layouts
+func Layouts() []string {
+	return []string{"default", "numpad", "arrows"}
+}
+
+// DefaultBindings returns the bindings of the given built-in keypad layout and the default commands
+func DefaultBindings(layout string) (*Bindings, error) {
+	keys, ok := layouts[layout]
+	if !ok {
+		return nil, fmt.Errorf("unknown keypad layout %q", layout)
+	}
+
+	b := Bindings{Commands: map[Action][]Binding{}}
+	for key, names := range keys {
+		for _, name := range names {
+			b.Keypad[key] = append(b.Keypad[key], Binding{Key: name})
+		}
+	}
+	for action, names := range defaultCommands {
+		for _, name := range names {
+			binding, _ := ParseBinding(name)
+			b.Commands[action] = append(b.Commands[action], binding)
+		}
+	}
+
+	return &b, nil
+}
+
+// LoadBindings loads the key bindings from the given file.
+// The file selects a built-in layout and may override the keys of single CHIP-8 keys or commands.
+// If the file doesn't exist, the default bindings are returned.
+func LoadBindings(path string) (*Bindings, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return DefaultBindings("default")
+	} else if err != nil {
+		return nil, err
+	}
+
+	var file bindingsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid key bindings file %v: %v", path, err)
+	}
+	if file.Layout == "" {
+		file.Layout = "default"
+	}
+
+	b, err := DefaultBindings(file.Layout)
+	if err != nil {
+		return nil, err
+	}
+	for name, bindings := range file.Keypad {
+		key, err := strconv.ParseUint(name, 16, 8)
+		if err != nil || key > 0xF {
+			return nil, fmt.Errorf("invalid CHIP-8 key %q in %v", name, path)
+		}
+		b.Keypad[key] = bindings
+	}
+	for action, bindings := range file.Commands {
+		if _, ok := actionDescriptions[action]; !ok {
+			return nil, fmt.Errorf("unknown command %q in %v", action, path)
+		}
+		b.Commands[action] = bindings
+	}
+	for name, rate := range file.Autofire {
+		key, err := strconv.ParseUint(name, 16, 8)
+		if err != nil || key > 0xF || rate < 0 {
+			return nil, fmt.Errorf("invalid autofire for CHIP-8 key %q in %v", name, path)
+		}
+		b.Autofire[key] = rate
+	}
+	b.Macros = file.Macros
+
+	return b, nil
+}
+
+// HelpLines returns the instructions for all commands with their active bindings
+func (b *Bindings) HelpLines() []string {
+	lines := []string{}
+	for _, action := range Actions {
+		if len(b.Commands[action]) == 0 {
+			continue
+		}
+		lines = append(lines, helpLine(b.Commands[action], action.Description()))
+	}
+	for _, macro := range b.Macros {
+		if len(macro.Keys) > 0 {
+			lines = append(lines, helpLine(macro.Keys, fmt.Sprintf("Macro %v", macro.Name)))
+		}
+	}
+	return lines
+}
+
+func helpLine(bindings []Binding, description string) string {
+	keys := []string{}
+	for _, binding := range bindings {
+		keys = append(keys, strings.Replace(binding.String(), "+", " + ", 1))
+	}
+	return fmt.Sprintf("%-16v%v", strings.Join(keys, ", "), description)
+}
@@ -0,0 +1,113 @@
+package input
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBinding(t *testing.T) {
+	assert := assert.New(t)
+
+	b, err := ParseBinding("Ctrl+O")
+	assert.Nil(err)
+	assert.Equal(Binding{Key: "O", Ctrl: true}, b)
+	assert.Equal("Ctrl+O", b.String())
+
+	b, err = ParseBinding("F1")
+	assert.Nil(err)
+	assert.Equal(Binding{Key: "F1"}, b)
+
+	_, err = ParseBinding("Ctrl+")
+	assert.NotNil(err)
+}
+
+func TestDefaultBindings(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, layout := range Layouts() {
+		b, err := DefaultBindings(layout)
+		assert.Nil(err)
+		for key := range b.Keypad {
+			assert.NotEmpty(b.Keypad[key])
+		}
+		for _, action := range Actions {
+			assert.NotEmpty(b.Commands[action])
+			assert.NotEmpty(action.Description())
+		}
+	}
+
+	b, _ := DefaultBindings("default")
+	assert.Equal([]Binding{{Key: "X"}}, b.Keypad[0])
+	assert.Equal([]Binding{{Key: "V"}}, b.Keypad[0xF])
+	assert.Equal([]Binding{{Key: "O", Ctrl: true}}, b.Commands[OpenRom])
+
+	_, err := DefaultBindings("unknown")
+	assert.NotNil(err)
+}
+
+func TestLoadBindings(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "pich8-go")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "bindings.json")
+
+	// Missing file
+	b, err := LoadBindings(path)
+	assert.Nil(err)
+	assert.Equal([]Binding{{Key: "X"}}, b.Keypad[0])
+
+	// Layout with overrides
+	ioutil.WriteFile(path, []byte(`{
+		"layout": "arrows",
+		"keypad": {"a": ["Z", "KP0"]},
+		"commands": {"Pause": ["Space", "Ctrl+P"]},
+		"autofire": {"5": 3},
+		"macros": [{"name": "jump", "keys": ["J"], "steps": [{"keys": ["5"], "frames": 2}]}]
+	}`), 0644)
+	b, err = LoadBindings(path)
+	assert.Nil(err)
+	assert.Equal([]Binding{{Key: "2"}, {Key: "Up"}}, b.Keypad[2])
+	assert.Equal([]Binding{{Key: "Z"}, {Key: "KP0"}}, b.Keypad[0xA])
+	assert.Equal([]Binding{{Key: "Space"}, {Key: "P", Ctrl: true}}, b.Commands[Pause])
+	assert.Equal([]Binding{{Key: "M"}}, b.Commands[Mute])
+	assert.Equal(3, b.Autofire[5])
+	assert.Equal(0, b.Autofire[6])
+	assert.Len(b.Macros, 1)
+	assert.Equal([]Binding{{Key: "J"}}, b.Macros[0].Keys)
+
+	// Errors
+	ioutil.WriteFile(path, []byte(`{"keypad": {"10": ["Z"]}}`), 0644)
+	_, err = LoadBindings(path)
+	assert.NotNil(err)
+	ioutil.WriteFile(path, []byte(`{"commands": {"Unknown": ["Z"]}}`), 0644)
+	_, err = LoadBindings(path)
+	assert.NotNil(err)
+	ioutil.WriteFile(path, []byte(`{"autofire": {"5": -1}}`), 0644)
+	_, err = LoadBindings(path)
+	assert.NotNil(err)
+	ioutil.WriteFile(path, []byte(`{"layout": "unknown"}`), 0644)
+	_, err = LoadBindings(path)
+	assert.NotNil(err)
+}
+
+func TestHelpLines(t *testing.T) {
+	assert := assert.New(t)
+
+	b, _ := DefaultBindings("default")
+	b.Commands[Pause] = []Binding{{Key: "P"}, {Key: "Space"}}
+	delete(b.Commands, Mute)
+
+	lines := b.HelpLines()
+	assert.Equal(len(Actions)-1, len(lines))
+	assert.Equal("Ctrl + O        Open ROM", lines[0])
+	assert.Contains(lines, "P, Space        Pause on/off")
+
+	b.Macros = []Macro{{Name: "jump", Keys: []Binding{{Key: "J", Ctrl: true}}}}
+	assert.Equal("Ctrl + J        Macro jump", b.HelpLines()[len(Actions)-1])
+}
@@ -0,0 +1,133 @@
+package input
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Macro is a named sequence of key states which is played when one of its keys is pressed
+type Macro struct {
+	Name  string      `json:"name"`
+	Keys  []Binding   `json:"keys"`
+	Steps []MacroStep `json:"steps"`
+}
+
+// MacroStep holds the given CHIP-8 keys down for a number of frames
+type MacroStep struct {
+	Keys   [16]bool
+	Frames int
+}
+
+type macroStepFile struct {
+	Keys   []string `json:"keys"`
+	Frames int      `json:"frames"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (s MacroStep) MarshalJSON() ([]byte, error) {
+	file := macroStepFile{Keys: []string{}, Frames: s.Frames}
+	for key, pressed := range s.Keys {
+		if pressed {
+			file.Keys = append(file.Keys, fmt.Sprintf("%X", key))
+		}
+	}
+	return json.Marshal(file)
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (s *MacroStep) UnmarshalJSON(data []byte) error {
+	var file macroStepFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return err
+	}
+	if file.Frames <= 0 {
+		return fmt.Errorf("invalid number of frames %v in macro step", file.Frames)
+	}
+
+	*s = MacroStep{Frames: file.Frames}
+	for _, name := range file.Keys {
+		key, err := strconv.ParseUint(name, 16, 8)
+		if err != nil || key > 0xF {
+			return fmt.Errorf("invalid CHIP-8 key %q in macro step", name)
+		}
+		s.Keys[key] = true
+	}
+	return nil
+}
+
+// Processor applies autofire and macros to the key states before they're passed to the CPU.
+// Time is measured in frames, i.e. timer ticks at 60 Hz.
+type Processor struct {
+	// Autofire rate in frames per CHIP-8 key, 0 disables autofire for the key
+	autofire        [16]int
+	AutofireEnabled bool
+
+	macros     []Macro
+	macro      *Macro
+	macroStart int
+	held       [16]bool
+	heldSince  [16]int
+}
+
+// NewProcessor creates a new instance with the autofire rates and macros of the given bindings
+func NewProcessor(bindings *Bindings) *Processor {
+	return &Processor{
+		autofire:        bindings.Autofire,
+		AutofireEnabled: true,
+		macros:          bindings.Macros,
+	}
+}
+
+// StartMacro starts playing the macro with the given index at the given frame
+func (p *Processor) StartMacro(index int, frame int) {
+	if index < 0 || index >= len(p.macros) {
+		return
+	}
+	p.macro = &p.macros[index]
+	p.macroStart = frame
+}
+
+// MacroActive returns whether a macro is playing
+func (p *Processor) MacroActive() bool {
+	return p.macro != nil
+}
+
+// Process returns the key states at the given frame with autofire and the active macro applied
+func (p *Processor) Process(keys [16]bool, frame int) [16]bool {
+	out := keys
+
+	// Autofire alternates between pressed and released while the key is held
+	for key, pressed := range keys {
+		if pressed && !p.held[key] {
+			p.heldSince[key] = frame
+		}
+		p.held[key] = pressed
+
+		rate := p.autofire[key]
+		if pressed && p.AutofireEnabled && rate > 0 {
+			out[key] = (frame-p.heldSince[key])/rate%2 == 0
+		}
+	}
+
+	// Macro keys are added to the pressed keys
+	if p.macro != nil {
+		elapsed := frame - p.macroStart
+		done := true
+		for _, step := range p.macro.Steps {
+			if elapsed < step.Frames {
+				for key, pressed := range step.Keys {
+					out[key] = out[key] || pressed
+				}
+				done = false
+				break
+			}
+			elapsed -= step.Frames
+		}
+		if done {
+			p.macro = nil
+		}
+	}
+
+	return out
+}
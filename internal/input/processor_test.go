@@ -0,0 +1,73 @@
+package input
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutofire(t *testing.T) {
+	assert := assert.New(t)
+
+	b, _ := DefaultBindings("default")
+	b.Autofire[5] = 2
+	p := NewProcessor(b)
+
+	var keys [16]bool
+	keys[5] = true
+	keys[6] = true
+	expected := []bool{true, true, false, false, true, true, false}
+	for i, pressed := range expected {
+		out := p.Process(keys, 10+i)
+		assert.Equal(pressed, out[5], "frame %v", i)
+		assert.True(out[6])
+	}
+
+	// Released keys stay released, pressing again restarts the cycle
+	out := p.Process([16]bool{}, 20)
+	assert.False(out[5])
+	assert.True(p.Process(keys, 21)[5])
+	assert.True(p.Process(keys, 22)[5])
+	assert.False(p.Process(keys, 23)[5])
+
+	p.AutofireEnabled = false
+	assert.True(p.Process(keys, 24)[5])
+}
+
+func TestMacro(t *testing.T) {
+	assert := assert.New(t)
+
+	var steps []MacroStep
+	assert.Nil(json.Unmarshal([]byte(`[{"keys": ["5"], "frames": 2}, {"keys": [], "frames": 1}, {"keys": ["a", "F"], "frames": 1}]`), &steps))
+	assert.Len(steps, 3)
+	assert.True(steps[2].Keys[0xA])
+	assert.True(steps[2].Keys[0xF])
+
+	data, err := json.Marshal(steps[2])
+	assert.Nil(err)
+	assert.JSONEq(`{"keys": ["A", "F"], "frames": 1}`, string(data))
+
+	b, _ := DefaultBindings("default")
+	b.Macros = []Macro{{Name: "test", Steps: steps}}
+	p := NewProcessor(b)
+
+	// Unknown macros are ignored
+	p.StartMacro(1, 0)
+	assert.False(p.MacroActive())
+
+	p.StartMacro(0, 5)
+	assert.True(p.MacroActive())
+	var keys [16]bool
+	keys[1] = true
+	assert.Equal([16]bool{1: true, 5: true}, p.Process(keys, 5))
+	assert.Equal([16]bool{1: true, 5: true}, p.Process(keys, 6))
+	assert.Equal([16]bool{1: true}, p.Process(keys, 7))
+	assert.Equal([16]bool{1: true, 0xA: true, 0xF: true}, p.Process(keys, 8))
+	assert.True(p.MacroActive())
+	assert.Equal([16]bool{1: true}, p.Process(keys, 9))
+	assert.False(p.MacroActive())
+
+	assert.NotNil(json.Unmarshal([]byte(`{"keys": ["G"], "frames": 1}`), &MacroStep{}))
+	assert.NotNil(json.Unmarshal([]byte(`{"keys": ["1"], "frames": 0}`), &MacroStep{}))
+}
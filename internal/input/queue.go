@@ -0,0 +1,58 @@
+package input
+
+import "time"
+
+// Event is a change of the state of a CHIP-8 key
+type Event struct {
+	Time    time.Time
+	Key     byte
+	Pressed bool
+}
+
+// Queue collects key events in chronological order until they're consumed by the emulation
+type Queue struct {
+	events []Event
+	head   int
+	state  [16]bool
+}
+
+// Update compares the given key states with the last ones and queues an event for every change
+func (q *Queue) Update(state [16]bool, now time.Time) {
+	for key, pressed := range state {
+		if pressed != q.state[key] {
+			q.Push(Event{Time: now, Key: byte(key), Pressed: pressed})
+		}
+	}
+}
+
+// Push queues the given event
+func (q *Queue) Push(event Event) {
+	q.events = append(q.events, event)
+	q.state[event.Key&0xF] = event.Pressed
+}
+
+// Next removes and returns the oldest event if it happened at or before the given time
+func (q *Queue) Next(until time.Time) (Event, bool) {
+	if q.head >= len(q.events) || q.events[q.head].Time.After(until) {
+		return Event{}, false
+	}
+
+	event := q.events[q.head]
+	q.head++
+	if q.head == len(q.events) {
+		// Reuse the underlying array
+		q.events = q.events[:0]
+		q.head = 0
+	}
+	return event, true
+}
+
+// Len returns the number of queued events
+func (q *Queue) Len() int {
+	return len(q.events) - q.head
+}
+
+// State returns the key states after all queued events
+func (q *Queue) State() [16]bool {
+	return q.state
+}
@@ -0,0 +1,43 @@
+package input
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueue(t *testing.T) {
+	assert := assert.New(t)
+
+	start := time.Now()
+	q := Queue{}
+	q.Update([16]bool{1: true}, start)
+	q.Update([16]bool{1: true, 2: true}, start.Add(time.Millisecond))
+	q.Update([16]bool{2: true}, start.Add(2*time.Millisecond))
+	q.Update([16]bool{2: true}, start.Add(3*time.Millisecond))
+	assert.Equal(3, q.Len())
+	assert.Equal([16]bool{2: true}, q.State())
+
+	e, ok := q.Next(start)
+	assert.True(ok)
+	assert.Equal(Event{Time: start, Key: 1, Pressed: true}, e)
+	_, ok = q.Next(start)
+	assert.False(ok)
+
+	e, ok = q.Next(start.Add(5 * time.Millisecond))
+	assert.True(ok)
+	assert.EqualValues(2, e.Key)
+	assert.True(e.Pressed)
+	e, ok = q.Next(start.Add(5 * time.Millisecond))
+	assert.True(ok)
+	assert.EqualValues(1, e.Key)
+	assert.False(e.Pressed)
+	assert.Equal(0, q.Len())
+	_, ok = q.Next(start.Add(5 * time.Millisecond))
+	assert.False(ok)
+
+	q.Push(Event{Time: start, Key: 4, Pressed: true})
+	assert.Equal(1, q.Len())
+	assert.Equal([16]bool{2: true, 4: true}, q.State())
+}
@@ -0,0 +1,318 @@
+package movie
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/philw07/pich8-go/internal/cpu"
+	"github.com/philw07/pich8-go/internal/videomemory"
+)
+
+const magic = "pich8-movie 1"
+
+// Event is the state of all CHIP-8 keys starting at a frame
+type Event struct {
+	Frame int
+	Keys  [16]bool
+}
+
+// Movie is a recorded session which can be replayed deterministically.
+// Each frame consists of Speed/60 instructions followed by one timer tick.
+type Movie struct {
+	RomHash string
+	Quirks  cpu.Quirks
+	Seed    int64
+	Speed   int
+	Events  []Event
+
+	// Frame count and framebuffer hash at the end of the recording
+	EndFrame  int
+	FinalHash string
+}
+
+// RomHash returns the SHA-1 hash of the given ROM as hex string
+func RomHash(rom []byte) string {
+	return fmt.Sprintf("%x", sha1.Sum(rom))
+}
+
+// FramebufferHash returns the SHA-1 hash of the visible content of the given VideoMemory
+func FramebufferHash(vmem videomemory.VideoMemory) string {
+	hash := sha1.New()
+	fmt.Fprintf(hash, "%v %v %v\n", vmem.VideoMode, vmem.RenderWidth(), vmem.RenderHeight())
+	buf := make([]byte, 0, vmem.RenderWidth()*vmem.RenderHeight())
+	for i := 0; i < vmem.RenderWidth()*vmem.RenderHeight(); i++ {
+		var b byte
+		if vmem.GetIndex(videomemory.FirstPlane, i) {
+			b |= 1
+		}
+		if vmem.GetIndex(videomemory.SecondPlane, i) {
+			b |= 2
+		}
+		buf = append(buf, b)
+	}
+	hash.Write(buf)
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
+
+// Record appends an event if the key states differ from the last recorded ones
+func (m *Movie) Record(frame int, keys [16]bool) {
+	if len(m.Events) > 0 && m.Events[len(m.Events)-1].Keys == keys {
+		return
+	}
+	if len(m.Events) == 0 && keys == [16]bool{} {
+		return
+	}
+	m.Events = append(m.Events, Event{Frame: frame, Keys: keys})
+}
+
+// InstructionsPerFrame returns the number of instructions executed per frame
+func (m *Movie) InstructionsPerFrame() int {
+	return m.Speed / 60
+}
+
+// NewCPU creates a CPU in the state the recording started with
+func (m *Movie) NewCPU(rom []byte) (*cpu.CPU, error) {
+	if hash := RomHash(rom); hash != m.RomHash {
+		return nil, fmt.Errorf("ROM doesn't match the movie, expected hash %v but got %v", m.RomHash, hash)
+	}
+
+	c := cpu.NewCPU()
+	if err := c.LoadRom(rom); err != nil {
+		return nil, err
+	}
+	c.SetQuirks(m.Quirks)
+	c.Seed(m.Seed)
+	return c, nil
+}
+
+// RunFrame emulates one frame with the given key states
+func RunFrame(c *cpu.CPU, keys [16]bool, instructionsPerFrame int) {
+	for i := 0; i < instructionsPerFrame; i++ {
+		c.Tick(keys)
+	}
+	c.UpdateTimers()
+}
+
+// Replay runs the whole movie without rendering or audio and returns the final framebuffer hash
+func Replay(m *Movie, rom []byte) (string, error) {
+	c, err := m.NewCPU(rom)
+	if err != nil {
+		return "", err
+	}
+
+	player := NewPlayer(m)
+	for frame := 0; frame < m.EndFrame; frame++ {
+		RunFrame(c, player.Keys(frame), m.InstructionsPerFrame())
+	}
+	return FramebufferHash(c.Vmem()), nil
+}
+
+// Player replays the key states of a movie frame by frame
+type Player struct {
+	movie *Movie
+	next  int
+	keys  [16]bool
+}
+
+// NewPlayer creates a new instance
+func NewPlayer(m *Movie) *Player {
+	return &Player{movie: m}
+}
+
+// Keys returns the key states at the given frame, the frames must be requested in ascending order
+func (p *Player) Keys(frame int) [16]bool {
+	for p.next < len(p.movie.Events) && p.movie.Events[p.next].Frame <= frame {
+		p.keys = p.movie.Events[p.next].Keys
+		p.next++
+	}
+	return p.keys
+}
+
+// Done returns whether the given frame is past the end of the recording
+func (p *Player) Done(frame int) bool {
+	return frame >= p.movie.EndFrame
+}
+
+// Write writes the movie in its text format
+func (m *Movie) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, magic)
+	fmt.Fprintf(bw, "rom %v\n", m.RomHash)
+	fmt.Fprintf(bw, "profile %v\n", cpu.ProfileName(m.Quirks))
+	fmt.Fprintf(bw, "quirks %v\n", formatQuirks(m.Quirks))
+	fmt.Fprintf(bw, "seed %v\n", m.Seed)
+	fmt.Fprintf(bw, "speed %v\n", m.Speed)
+	for _, event := range m.Events {
+		fmt.Fprintf(bw, "keys %v %04x\n", event.Frame, keysToMask(event.Keys))
+	}
+	fmt.Fprintf(bw, "end %v %v\n", m.EndFrame, m.FinalHash)
+	return bw.Flush()
+}
+
+// Save writes the movie to the given file
+func (m *Movie) Save(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := m.Write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Read parses a movie in its text format
+func Read(r io.Reader) (*Movie, error) {
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() || scanner.Text() != magic {
+		return nil, errors.New("not a movie file")
+	}
+
+	m := Movie{Quirks: cpu.Profiles[cpu.DefaultProfile]}
+	ended := false
+	for line := 2; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		var err error
+		switch fields[0] {
+		case "rom":
+			err = expectFields(fields, 2)
+			if err == nil {
+				m.RomHash = fields[1]
+			}
+		case "profile":
+			// Informational only, the quirks are authoritative
+		case "quirks":
+			m.Quirks, err = parseQuirks(fields[1:])
+		case "seed":
+			err = expectFields(fields, 2)
+			if err == nil {
+				m.Seed, err = strconv.ParseInt(fields[1], 10, 64)
+			}
+		case "speed":
+			err = expectFields(fields, 2)
+			if err == nil {
+				m.Speed, err = strconv.Atoi(fields[1])
+			}
+		case "keys":
+			err = expectFields(fields, 3)
+			if err == nil {
+				var event Event
+				var mask uint64
+				event.Frame, err = strconv.Atoi(fields[1])
+				if err == nil {
+					mask, err = strconv.ParseUint(fields[2], 16, 16)
+					event.Keys = maskToKeys(uint16(mask))
+					m.Events = append(m.Events, event)
+				}
+			}
+		case "end":
+			err = expectFields(fields, 3)
+			if err == nil {
+				m.EndFrame, err = strconv.Atoi(fields[1])
+				m.FinalHash = fields[2]
+				ended = true
+			}
+		default:
+			err = fmt.Errorf("unknown entry %q", fields[0])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %v: %v", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !ended {
+		return nil, errors.New("movie file is incomplete")
+	}
+	if m.Speed < 60 {
+		return nil, fmt.Errorf("invalid speed %v", m.Speed)
+	}
+
+	return &m, nil
+}
+
+// Load reads the movie from the given file
+func Load(path string) (*Movie, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return Read(file)
+}
+
+func expectFields(fields []string, n int) error {
+	if len(fields) != n {
+		return fmt.Errorf("expected %v values for %q", n-1, fields[0])
+	}
+	return nil
+}
+
+func formatQuirks(q cpu.Quirks) string {
+	b := func(v bool) int {
+		if v {
+			return 1
+		}
+		return 0
+	}
+	return fmt.Sprintf("loadstore=%v shift=%v jump=%v vforder=%v draw=%v", b(q.LoadStore), b(q.Shift), b(q.Jump), b(q.VfOrder), b(q.Draw))
+}
+
+func parseQuirks(fields []string) (cpu.Quirks, error) {
+	var q cpu.Quirks
+	for _, field := range fields {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 {
+			return q, fmt.Errorf("invalid quirk %q", field)
+		}
+		val, err := strconv.ParseBool(parts[1])
+		if err != nil {
+			return q, fmt.Errorf("invalid quirk %q", field)
+		}
+		switch parts[0] {
+		case "loadstore":
+			q.LoadStore = val
+		case "shift":
+			q.Shift = val
+		case "jump":
+			q.Jump = val
+		case "vforder":
+			q.VfOrder = val
+		case "draw":
+			q.Draw = val
+		default:
+			return q, fmt.Errorf("unknown quirk %q", parts[0])
+		}
+	}
+	return q, nil
+}
+
+func keysToMask(keys [16]bool) uint16 {
+	var mask uint16
+	for i, pressed := range keys {
+		if pressed {
+			mask |= 1 << i
+		}
+	}
+	return mask
+}
+
+func maskToKeys(mask uint16) [16]bool {
+	var keys [16]bool
+	for i := range keys {
+		keys[i] = mask>>i&1 == 1
+	}
+	return keys
+}
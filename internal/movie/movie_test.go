@@ -0,0 +1,99 @@
+package movie
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/philw07/pich8-go/internal/cpu"
+	"github.com/stretchr/testify/assert"
+)
+
+// Draws the digit of the pressed key at a random position
+var testRom = []byte{
+	0xF0, 0x0A, // V0 = get_key()
+	0xF0, 0x29, // I = sprite_add(V0)
+	0xC1, 0x3F, // V1 = rand() & 0x3F
+	0xC2, 0x1F, // V2 = rand() & 0x1F
+	0xD1, 0x25, // draw(V1, V2, 5)
+	0x12, 0x00, // goto 0x200
+}
+
+func record(seed int64) *Movie {
+	m := &Movie{
+		RomHash: RomHash(testRom),
+		Quirks:  cpu.Profiles["chip8"],
+		Seed:    seed,
+		Speed:   600,
+	}
+	c, _ := m.NewCPU(testRom)
+
+	frame := 0
+	for _, key := range []int{1, 5, 0xA, 0xF} {
+		for i := 0; i < 3; i++ {
+			var keys [16]bool
+			keys[key] = i == 0
+			m.Record(frame, keys)
+			RunFrame(c, keys, m.InstructionsPerFrame())
+			frame++
+		}
+	}
+	m.EndFrame = frame
+	m.FinalHash = FramebufferHash(c.Vmem())
+	return m
+}
+
+func TestReplay(t *testing.T) {
+	assert := assert.New(t)
+
+	m := record(42)
+	assert.Len(m.Events, 8)
+
+	hash, err := Replay(m, testRom)
+	assert.Nil(err)
+	assert.Equal(m.FinalHash, hash)
+
+	// Different seed leads to different positions
+	assert.NotEqual(m.FinalHash, record(43).FinalHash)
+
+	// Wrong ROM
+	_, err = Replay(m, []byte{0x12, 0x00})
+	assert.NotNil(err)
+}
+
+func TestReadWrite(t *testing.T) {
+	assert := assert.New(t)
+
+	m := record(1234)
+	buf := bytes.Buffer{}
+	assert.Nil(m.Write(&buf))
+
+	read, err := Read(&buf)
+	assert.Nil(err)
+	assert.Equal(m, read)
+
+	_, err = Read(bytes.NewBufferString("something else\n"))
+	assert.NotNil(err)
+	_, err = Read(bytes.NewBufferString(magic + "\nspeed 600\n"))
+	assert.NotNil(err)
+	_, err = Read(bytes.NewBufferString(magic + "\nquirks jump=x\nend 1 abc\n"))
+	assert.NotNil(err)
+}
+
+func TestPlayer(t *testing.T) {
+	assert := assert.New(t)
+
+	m := &Movie{
+		Events: []Event{
+			{Frame: 2, Keys: [16]bool{1: true}},
+			{Frame: 5, Keys: [16]bool{}},
+		},
+		EndFrame: 7,
+	}
+	p := NewPlayer(m)
+	assert.Equal([16]bool{}, p.Keys(0))
+	assert.Equal([16]bool{1: true}, p.Keys(2))
+	assert.Equal([16]bool{1: true}, p.Keys(4))
+	assert.Equal([16]bool{}, p.Keys(5))
+	assert.False(p.Done(6))
+	assert.True(p.Done(7))
+}
@@ -0,0 +1,202 @@
+package palette
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/philw07/pich8-go/internal/videomemory"
+)
+
+// Palette contains the colors for the background, the first plane, the second plane and both planes
+type Palette [4]Color
+
+// Color is a color which is stored as hex string like "#ff8000"
+type Color color.RGBA
+
+// Theme is a named palette
+type Theme struct {
+	Name    string
+	Palette Palette
+}
+
+// Default is the palette used if none is configured
+var Default = Palette{
+	{R: 0x00, G: 0x00, B: 0x00, A: 0xff},
+	{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+	{R: 0xa8, G: 0xa8, B: 0xa8, A: 0xff},
+	{R: 0x54, G: 0x54, B: 0x54, A: 0xff},
+}
+
+// Themes contains the built-in themes in the order they're switched through
+var Themes = []Theme{
+	{"default", Default},
+	{"octo", mustParse("#996600,#ffcc00,#ff6600,#662200")},
+	{"lcd", mustParse("#9bbc0f,#0f380f,#306230,#8bac0f")},
+	{"amber", mustParse("#1a0f00,#ffb000,#a86400,#553300")},
+	{"contrast", mustParse("#000000,#ffffff,#ffff00,#00ffff")},
+	// Okabe-Ito colors, which are distinguishable with all common kinds of color blindness
+	{"colorblind", mustParse("#000000,#e69f00,#56b4e9,#ffffff")},
+}
+
+// LoadThemes loads the user palettes from the given file, which maps names to palettes,
+// and returns them after the built-in themes. A missing file results in the built-in themes.
+func LoadThemes(path string) ([]Theme, error) {
+	themes := append([]Theme{}, Themes...)
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return themes, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var file map[string]Palette
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid palettes file %v: %v", path, err)
+	}
+	for _, name := range sortedNames(file) {
+		theme := Theme{Name: name, Palette: file[name]}
+		if idx := FindTheme(themes, name); idx >= 0 {
+			themes[idx] = theme
+		} else {
+			themes = append(themes, theme)
+		}
+	}
+	return themes, nil
+}
+
+// FindTheme returns the index of the theme with the given name or -1
+func FindTheme(themes []Theme, name string) int {
+	for i, theme := range themes {
+		if theme.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Parse parses a theme name or four comma-separated colors like "#000000,#ffffff,#a8a8a8,#545454"
+func Parse(themes []Theme, s string) (Palette, error) {
+	if idx := FindTheme(themes, s); idx >= 0 {
+		return themes[idx].Palette, nil
+	}
+
+	var p Palette
+	colors := strings.Split(s, ",")
+	if len(colors) != len(p) {
+		return p, fmt.Errorf("invalid palette %q, expected a theme name or %v colors", s, len(p))
+	}
+	for i, c := range colors {
+		var err error
+		if p[i], err = ParseColor(strings.TrimSpace(c)); err != nil {
+			return p, err
+		}
+	}
+	return p, nil
+}
+
+func mustParse(s string) Palette {
+	p, err := Parse(nil, s)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func (p Palette) String() string {
+	colors := []string{}
+	for _, c := range p {
+		colors = append(colors, c.String())
+	}
+	return strings.Join(colors, ",")
+}
+
+// UnmarshalJSON implements json.Unmarshaler, all colors are required
+func (p *Palette) UnmarshalJSON(data []byte) error {
+	var colors []Color
+	if err := json.Unmarshal(data, &colors); err != nil {
+		return err
+	}
+	if len(colors) != len(p) {
+		return fmt.Errorf("invalid palette, expected %v colors but got %v", len(p), len(colors))
+	}
+	copy(p[:], colors)
+	return nil
+}
+
+// Index returns the palette index of the pixel with the given index
+func Index(vmem *videomemory.VideoMemory, index int) int {
+	idx := 0
+	if vmem.GetIndex(videomemory.FirstPlane, index) {
+		idx |= 1
+	}
+	if vmem.GetIndex(videomemory.SecondPlane, index) {
+		idx |= 2
+	}
+	return idx
+}
+
+// Render draws the visible content of the given VideoMemory to the given image, which is allocated if nil or of the wrong size
+func (p Palette) Render(vmem *videomemory.VideoMemory, img *image.RGBA) *image.RGBA {
+	w, h := vmem.RenderWidth(), vmem.RenderHeight()
+	if img == nil || img.Rect.Dx() != w || img.Rect.Dy() != h {
+		img = image.NewRGBA(image.Rect(0, 0, w, h))
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetRGBA(x, y, color.RGBA(p[Index(vmem, vmem.ToIndex(x, y))]))
+		}
+	}
+	return img
+}
+
+// ParseColor parses a color in the format "#rrggbb"
+func ParseColor(s string) (Color, error) {
+	var c Color
+	if len(s) != 7 || s[0] != '#' {
+		return c, fmt.Errorf("invalid color %q", s)
+	}
+	if _, err := fmt.Sscanf(s[1:], "%02x%02x%02x", &c.R, &c.G, &c.B); err != nil {
+		return c, fmt.Errorf("invalid color %q", s)
+	}
+	c.A = 0xff
+	return c, nil
+}
+
+func (c Color) String() string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (c Color) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (c *Color) UnmarshalText(text []byte) error {
+	parsed, err := ParseColor(string(text))
+	if err != nil {
+		return err
+	}
+	*c = parsed
+	return nil
+}
+
+// RGBA implements color.Color
+func (c Color) RGBA() (r, g, b, a uint32) {
+	return color.RGBA(c).RGBA()
+}
+
+func sortedNames(m map[string]Palette) []string {
+	names := []string{}
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
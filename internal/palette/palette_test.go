@@ -0,0 +1,99 @@
+package palette
+
+import (
+	"image/color"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/philw07/pich8-go/internal/videomemory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseColor(t *testing.T) {
+	assert := assert.New(t)
+
+	c, err := ParseColor("#ff8000")
+	assert.Nil(err)
+	assert.Equal(Color{R: 0xff, G: 0x80, B: 0x00, A: 0xff}, c)
+	assert.Equal("#ff8000", c.String())
+
+	for _, s := range []string{"ff8000", "#ff80", "#gg8000", "#ff800000"} {
+		_, err = ParseColor(s)
+		assert.NotNil(err, s)
+	}
+}
+
+func TestParse(t *testing.T) {
+	assert := assert.New(t)
+
+	p, err := Parse(Themes, "#000000,#ffffff, #a8a8a8,#545454")
+	assert.Nil(err)
+	assert.Equal(Default, p)
+	assert.Equal("#000000,#ffffff,#a8a8a8,#545454", p.String())
+
+	p, err = Parse(Themes, "octo")
+	assert.Nil(err)
+	assert.Equal("#996600,#ffcc00,#ff6600,#662200", p.String())
+
+	_, err = Parse(Themes, "#000000,#ffffff")
+	assert.NotNil(err)
+	_, err = Parse(Themes, "#000000,#ffffff,#a8a8a8,white")
+	assert.NotNil(err)
+	_, err = Parse(Themes, "unknown")
+	assert.NotNil(err)
+}
+
+func TestLoadThemes(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "pich8-go")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "palettes.json")
+
+	themes, err := LoadThemes(path)
+	assert.Nil(err)
+	assert.Equal(Themes, themes)
+
+	ioutil.WriteFile(path, []byte(`{
+		"red": ["#000000", "#ff0000", "#800000", "#400000"],
+		"amber": ["#000000", "#ffb000", "#a86400", "#553300"]
+	}`), 0644)
+	themes, err = LoadThemes(path)
+	assert.Nil(err)
+	assert.Len(themes, len(Themes)+1)
+	assert.Equal("red", themes[len(themes)-1].Name)
+	assert.Equal(Color{R: 0xff, A: 0xff}, themes[len(themes)-1].Palette[1])
+	assert.Equal(Color{A: 0xff}, themes[FindTheme(themes, "amber")].Palette[0])
+	assert.NotEqual(themes[FindTheme(Themes, "amber")], Themes[FindTheme(Themes, "amber")].Palette)
+	assert.Equal(-1, FindTheme(themes, "unknown"))
+
+	ioutil.WriteFile(path, []byte(`{"red": ["#000000"]}`), 0644)
+	_, err = LoadThemes(path)
+	assert.NotNil(err)
+}
+
+func TestRender(t *testing.T) {
+	assert := assert.New(t)
+
+	vmem := videomemory.NewVideoMemory()
+	vmem.Set(videomemory.FirstPlane, 1, 0, true)
+	vmem.Set(videomemory.SecondPlane, 2, 0, true)
+	vmem.Set(videomemory.FirstPlane, 3, 0, true)
+	vmem.Set(videomemory.SecondPlane, 3, 0, true)
+
+	p := Themes[FindTheme(Themes, "octo")].Palette
+	img := p.Render(vmem, nil)
+	assert.Equal(vmem.RenderWidth(), img.Rect.Dx())
+	assert.Equal(vmem.RenderHeight(), img.Rect.Dy())
+	for x := 0; x < 4; x++ {
+		// Low resolution pixels are doubled
+		assert.Equal(color.RGBA(p[x]), img.RGBAAt(2*x, 0))
+		assert.Equal(color.RGBA(p[x]), img.RGBAAt(2*x+1, 1))
+	}
+
+	// The image is reused if the size matches
+	assert.Same(img, p.Render(vmem, img))
+}
@@ -0,0 +1,325 @@
+package romdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/philw07/pich8-go/internal/cpu"
+	"github.com/philw07/pich8-go/internal/palette"
+)
+
+// Files of the community CHIP-8 database (https://github.com/chip-8/chip-8-database)
+const (
+	hashesFile    = "sha1-hashes.json"
+	programsFile  = "programs.json"
+	platformsFile = "platforms.json"
+)
+
+// platformProfiles maps the platform IDs of the database to the CPU profiles, in order of preference
+var platformProfiles = []struct {
+	platform string
+	profile  string
+}{
+	{"xochip", "xochip"},
+	{"superchip", "schip"},
+	{"superchip1", "schip"},
+	{"superchip1.1", "schip"},
+	{"modernChip8", "chip8"},
+	{"originalChip8", "chip8"},
+	{"hybridVIP", "chip8"},
+}
+
+// Entry holds the information and recommended settings of a ROM
+type Entry struct {
+	Title   string   `json:"title,omitempty"`
+	Authors []string `json:"authors,omitempty"`
+
+	// Platform ID as used in the database, e.g. "superchip"
+	Platform string `json:"platform,omitempty"`
+
+	// Instructions per frame
+	Tickrate int `json:"tickrate,omitempty"`
+
+	// Quirks with the names used in the database, e.g. "shift" or "memoryLeaveIUnchanged"
+	Quirks map[string]bool `json:"quirks,omitempty"`
+
+	// CHIP-8 keys of the game controls, e.g. "up" or "a"
+	Keys map[string]int `json:"keys,omitempty"`
+
+	// Colors for the background, the first plane, the second plane and both planes
+	Colors []string `json:"colors,omitempty"`
+
+	// Name of a palette theme, the colors take precedence
+	Theme string `json:"theme,omitempty"`
+}
+
+// Database contains the ROM entries keyed by their SHA-1 hash
+type Database struct {
+	entries   map[string]*Entry
+	overrides map[string]*Entry
+}
+
+type program struct {
+	Title   string          `json:"title"`
+	Authors []string        `json:"authors"`
+	Roms    map[string]*rom `json:"roms"`
+}
+
+type rom struct {
+	Platforms       []string                   `json:"platforms"`
+	Tickrate        int                        `json:"tickrate"`
+	Keys            map[string]int             `json:"keys"`
+	QuirkyPlatforms map[string]map[string]bool `json:"quirkyPlatforms"`
+	Colors          struct {
+		Pixels []string `json:"pixels"`
+	} `json:"colors"`
+}
+
+type platform struct {
+	ID              string          `json:"id"`
+	DefaultTickrate int             `json:"defaultTickrate"`
+	Quirks          map[string]bool `json:"quirks"`
+}
+
+// New creates an empty database
+func New() *Database {
+	return &Database{entries: map[string]*Entry{}, overrides: map[string]*Entry{}}
+}
+
+// Load loads the database files from the given directory,
+// if the directory doesn't exist, an empty database is returned
+func Load(dir string) (*Database, error) {
+	db := New()
+
+	var hashes map[string]int
+	if err := readJSON(filepath.Join(dir, hashesFile), &hashes); os.IsNotExist(err) {
+		return db, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var programs []program
+	if err := readJSON(filepath.Join(dir, programsFile), &programs); err != nil {
+		return nil, err
+	}
+	var platforms []platform
+	if err := readJSON(filepath.Join(dir, platformsFile), &platforms); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	platformsByID := map[string]platform{}
+	for _, p := range platforms {
+		platformsByID[p.ID] = p
+	}
+
+	for hash, idx := range hashes {
+		if idx < 0 || idx >= len(programs) {
+			return nil, fmt.Errorf("invalid program index %v for %v in %v", idx, hash, hashesFile)
+		}
+		prog := programs[idx]
+		r, ok := prog.Roms[hash]
+		if !ok {
+			r = &rom{}
+		}
+		db.entries[strings.ToLower(hash)] = newEntry(&prog, r, platformsByID)
+	}
+
+	return db, nil
+}
+
+func newEntry(prog *program, r *rom, platforms map[string]platform) *Entry {
+	entry := Entry{
+		Title:    prog.Title,
+		Authors:  prog.Authors,
+		Tickrate: r.Tickrate,
+		Keys:     r.Keys,
+		Colors:   r.Colors.Pixels,
+		Quirks:   map[string]bool{},
+	}
+
+	// Pick the most capable supported platform
+	for _, p := range platformProfiles {
+		for _, name := range r.Platforms {
+			if name == p.platform && entry.Platform == "" {
+				entry.Platform = name
+			}
+		}
+	}
+	if entry.Platform == "" && len(r.Platforms) > 0 {
+		entry.Platform = r.Platforms[0]
+	}
+
+	if p, ok := platforms[entry.Platform]; ok {
+		if entry.Tickrate == 0 {
+			entry.Tickrate = p.DefaultTickrate
+		}
+		for name, value := range p.Quirks {
+			entry.Quirks[name] = value
+		}
+	}
+	for name, value := range r.QuirkyPlatforms[entry.Platform] {
+		entry.Quirks[name] = value
+	}
+
+	return &entry
+}
+
+// LoadOverrides loads local entries keyed by SHA-1 hash from the given file,
+// their values take precedence over the database. A missing file is ignored, a file with null entries isn't loaded.
+func (db *Database) LoadOverrides(path string) error {
+	var overrides map[string]*Entry
+	if err := readJSON(path, &overrides); os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for hash, entry := range overrides {
+		if entry == nil {
+			return fmt.Errorf("%v: entry of %v is null", path, hash)
+		}
+	}
+	for hash, entry := range overrides {
+		db.overrides[strings.ToLower(hash)] = entry
+	}
+	return nil
+}
+
+// Lookup returns the entry of the ROM with the given SHA-1 hash
+func (db *Database) Lookup(hash string) (*Entry, bool) {
+	hash = strings.ToLower(hash)
+	entry, ok := db.entries[hash]
+	override, overridden := db.overrides[hash]
+	if !overridden {
+		return entry, ok
+	}
+	if !ok {
+		return override, true
+	}
+
+	merged := *entry
+	if override.Title != "" {
+		merged.Title = override.Title
+	}
+	if len(override.Authors) > 0 {
+		merged.Authors = override.Authors
+	}
+	if override.Platform != "" {
+		merged.Platform = override.Platform
+	}
+	if override.Tickrate > 0 {
+		merged.Tickrate = override.Tickrate
+	}
+	if len(override.Colors) > 0 {
+		merged.Colors = override.Colors
+	}
+	if override.Theme != "" {
+		merged.Theme = override.Theme
+	}
+	merged.Quirks = mergeMaps(entry.Quirks, override.Quirks)
+	merged.Keys = map[string]int{}
+	for name, key := range entry.Keys {
+		merged.Keys[name] = key
+	}
+	for name, key := range override.Keys {
+		merged.Keys[name] = key
+	}
+	return &merged, true
+}
+
+// Len returns the number of ROMs in the database including the local entries
+func (db *Database) Len() int {
+	n := len(db.entries)
+	for hash := range db.overrides {
+		if _, ok := db.entries[hash]; !ok {
+			n++
+		}
+	}
+	return n
+}
+
+// String returns the title and the authors
+func (e *Entry) String() string {
+	if len(e.Authors) == 0 {
+		return e.Title
+	}
+	return fmt.Sprintf("%v by %v", e.Title, strings.Join(e.Authors, ", "))
+}
+
+// Profile returns the CPU profile of the platform or the default profile if the platform isn't supported
+func (e *Entry) Profile() string {
+	for _, p := range platformProfiles {
+		if p.platform == e.Platform {
+			return p.profile
+		}
+	}
+	return cpu.DefaultProfile
+}
+
+// CPUQuirks returns the quirks of the profile with the supported quirks of the entry applied
+func (e *Entry) CPUQuirks() cpu.Quirks {
+	quirks := cpu.Profiles[e.Profile()]
+	for name, value := range e.Quirks {
+		switch name {
+		case "shift":
+			quirks.Shift = value
+		case "jump":
+			quirks.Jump = value
+		case "memoryLeaveIUnchanged":
+			quirks.LoadStore = value
+		}
+	}
+	return quirks
+}
+
+// Speed returns the CPU speed in instructions per second or 0 if the tickrate is unknown
+func (e *Entry) Speed() int {
+	return e.Tickrate * 60
+}
+
+// Palette returns the palette of the entry, missing colors are taken from the theme or the given palette
+func (e *Entry) Palette(themes []palette.Theme, base palette.Palette) (palette.Palette, error) {
+	p := base
+	if e.Theme != "" {
+		idx := palette.FindTheme(themes, e.Theme)
+		if idx < 0 {
+			return base, fmt.Errorf("unknown palette theme %q", e.Theme)
+		}
+		p = themes[idx].Palette
+	}
+	for i, s := range e.Colors {
+		if i >= len(p) {
+			break
+		}
+		c, err := palette.ParseColor(s)
+		if err != nil {
+			return base, err
+		}
+		p[i] = c
+	}
+	return p, nil
+}
+
+func mergeMaps(a, b map[string]bool) map[string]bool {
+	merged := map[string]bool{}
+	for name, value := range a {
+		merged[name] = value
+	}
+	for name, value := range b {
+		merged[name] = value
+	}
+	return merged
+}
+
+func readJSON(path string, v interface{}) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("invalid file %v: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package romdb
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/philw07/pich8-go/internal/cpu"
+	"github.com/philw07/pich8-go/internal/palette"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	hashBrix  = "bba1c6c8e4b1bfb7b3b9f0d4f2b8c8e7b7a2d1c0"
+	hashCar   = "0123456789abcdef0123456789abcdef01234567"
+	hashLocal = "fedcba9876543210fedcba9876543210fedcba98"
+)
+
+func writeDatabase(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "pich8-go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		hashesFile: `{"` + hashBrix + `": 0, "` + hashCar + `": 1}`,
+		programsFile: `[
+			{
+				"title": "Brix",
+				"authors": ["Andreas Gustafsson"],
+				"roms": {
+					"` + hashBrix + `": {
+						"platforms": ["originalChip8", "superchip"],
+						"keys": {"left": 4, "right": 6},
+						"colors": {"pixels": ["#101010", "#f0f0f0"]},
+						"quirkyPlatforms": {"superchip": {"shift": false}}
+					}
+				}
+			},
+			{
+				"title": "Car",
+				"roms": {
+					"` + hashCar + `": {"platforms": ["megachip8"], "tickrate": 50}
+				}
+			}
+		]`,
+		platformsFile: `[
+			{"id": "superchip", "defaultTickrate": 30, "quirks": {"shift": true, "jump": true, "memoryLeaveIUnchanged": true}},
+			{"id": "originalChip8", "defaultTickrate": 15, "quirks": {"shift": false}}
+		]`,
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoad(t *testing.T) {
+	assert := assert.New(t)
+
+	// Missing database
+	db, err := Load(filepath.Join(os.TempDir(), "pich8-go-missing"))
+	assert.Nil(err)
+	assert.Equal(0, db.Len())
+
+	dir := writeDatabase(t)
+	defer os.RemoveAll(dir)
+	db, err = Load(dir)
+	assert.Nil(err)
+	assert.Equal(2, db.Len())
+
+	_, ok := db.Lookup(hashLocal)
+	assert.False(ok)
+
+	// Most capable supported platform with its defaults and the ROM specific quirks
+	entry, ok := db.Lookup(hashBrix)
+	assert.True(ok)
+	assert.Equal("Brix by Andreas Gustafsson", entry.String())
+	assert.Equal("superchip", entry.Platform)
+	assert.Equal("schip", entry.Profile())
+	assert.Equal(30*60, entry.Speed())
+	expected := cpu.Profiles["schip"]
+	expected.Shift = false
+	assert.Equal(expected, entry.CPUQuirks())
+	assert.Equal(map[string]int{"left": 4, "right": 6}, entry.Keys)
+
+	p, err := entry.Palette(palette.Themes, palette.Default)
+	assert.Nil(err)
+	assert.Equal(palette.Color{R: 0x10, G: 0x10, B: 0x10, A: 0xff}, p[0])
+	assert.Equal(palette.Color{R: 0xf0, G: 0xf0, B: 0xf0, A: 0xff}, p[1])
+	assert.Equal(palette.Default[2], p[2])
+
+	// Unsupported platform
+	entry, ok = db.Lookup(hashCar)
+	assert.True(ok)
+	assert.Equal("Car", entry.String())
+	assert.Equal(cpu.DefaultProfile, entry.Profile())
+	assert.Equal(50*60, entry.Speed())
+
+	// Errors
+	ioutil.WriteFile(filepath.Join(dir, hashesFile), []byte(`{"`+hashBrix+`": 5}`), 0644)
+	_, err = Load(dir)
+	assert.NotNil(err)
+	ioutil.WriteFile(filepath.Join(dir, programsFile), []byte(`{`), 0644)
+	_, err = Load(dir)
+	assert.NotNil(err)
+}
+
+func TestOverrides(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := writeDatabase(t)
+	defer os.RemoveAll(dir)
+	db, err := Load(dir)
+	assert.Nil(err)
+
+	path := filepath.Join(dir, "roms.json")
+	assert.Nil(db.LoadOverrides(path))
+
+	ioutil.WriteFile(path, []byte(`{
+		"`+hashBrix+`": {"tickrate": 20, "quirks": {"jump": false}, "keys": {"a": 5}},
+		"`+hashLocal+`": {"title": "My Game", "platform": "xochip", "colors": ["#000000", "#ff0000", "#00ff00", "#0000ff"]}
+	}`), 0644)
+	assert.Nil(db.LoadOverrides(path))
+	assert.Equal(3, db.Len())
+
+	entry, ok := db.Lookup(hashBrix)
+	assert.True(ok)
+	assert.Equal("Brix", entry.Title)
+	assert.Equal(20*60, entry.Speed())
+	assert.False(entry.CPUQuirks().Jump)
+	assert.False(entry.CPUQuirks().Shift)
+	assert.Equal(map[string]int{"left": 4, "right": 6, "a": 5}, entry.Keys)
+
+	entry, ok = db.Lookup(hashLocal)
+	assert.True(ok)
+	assert.Equal("My Game", entry.String())
+	assert.Equal(cpu.Profiles["xochip"], entry.CPUQuirks())
+	assert.Equal(0, entry.Speed())
+	p, err := entry.Palette(palette.Themes, palette.Default)
+	assert.Nil(err)
+	assert.Equal(palette.Color{R: 0x00, G: 0x00, B: 0xff, A: 0xff}, p[3])
+
+	// Theme with single colors replaced
+	entry.Theme = "lcd"
+	entry.Colors = []string{"#000000"}
+	p, err = entry.Palette(palette.Themes, palette.Default)
+	assert.Nil(err)
+	lcd := palette.Themes[palette.FindTheme(palette.Themes, "lcd")].Palette
+	assert.Equal(palette.Color{A: 0xff}, p[0])
+	assert.Equal(lcd[1], p[1])
+
+	entry.Colors = []string{"red"}
+	_, err = entry.Palette(palette.Themes, palette.Default)
+	assert.NotNil(err)
+	entry.Colors = nil
+	entry.Theme = "unknown"
+	_, err = entry.Palette(palette.Themes, palette.Default)
+	assert.NotNil(err)
+
+	// Null entries are rejected and the file isn't loaded
+	db = New()
+	ioutil.WriteFile(path, []byte(`{"`+hashLocal+`": {"title": "My Game"}, "`+hashBrix+`": null}`), 0644)
+	err = db.LoadOverrides(path)
+	if assert.NotNil(err) {
+		assert.Contains(err.Error(), hashBrix)
+	}
+	_, ok = db.Lookup(hashLocal)
+	assert.False(ok)
+}
@@ -1,42 +1,133 @@
 package sound
 
 import (
+	"math"
 	"time"
 
 	"github.com/faiface/beep"
-	"github.com/faiface/beep/speaker"
 )
 
 const (
-	sampleRate = 48000
-	volume     = 0.05
+	// SampleRate is the sample rate of the generated audio stream
+	SampleRate     = 48000
+	timerFrequency = 60
+
+	DefaultVolume    = 0.25
+	DefaultFrequency = 440
+
+	// Scales the volume so that full volume isn't too loud
+	maxAmplitude = 0.2
 )
 
+// AudioPlayer generates the sound of the emulated machine and plays it on a Sink
 type AudioPlayer struct {
+	sink       Sink
 	sampleRate beep.SampleRate
-	queue      *queue
-	beeper     *beeper
-}
+	xoChip     *xoChipStream
+	tone       *tone
 
-func NewAudioPlayer() *AudioPlayer {
-	sr := beep.SampleRate(sampleRate)
-	speaker.Init(sr, sr.N(time.Second/15))
+	volume float64
+	mute   bool
+}
 
-	queue := queue{}
-	beeper := newBeeper(sr)
-	speaker.Play(beep.Mix(&queue, beeper))
+// NewAudioPlayer creates a new instance playing on the given sink
+func NewAudioPlayer(sink Sink) *AudioPlayer {
+	sr := beep.SampleRate(SampleRate)
+	xoChip := newXOChipStream(sr, sink.BufferSize())
+	tone := newTone(sr, SquareWave, DefaultFrequency, DefaultVolume*maxAmplitude)
+	sink.Play(beep.Mix(xoChip, tone))
 
 	return &AudioPlayer{
+		sink:       sink,
 		sampleRate: sr,
-		queue:      &queue,
-		beeper:     beeper,
+		xoChip:     xoChip,
+		tone:       tone,
+		volume:     DefaultVolume,
 	}
 }
 
-func (ap *AudioPlayer) Beep() {
-	ap.beeper.Play()
+// SetSoundTimer needs to be called whenever the ROM sets the sound timer, also if it's set to its current value.
+// The tone is played for exactly the given number of timer ticks from now on, independent of how the ticks are scheduled.
+func (ap *AudioPlayer) SetSoundTimer(st byte) {
+	ap.sink.Lock()
+	defer ap.sink.Unlock()
+	ap.tone.Gate(int(st) * ap.sampleRate.N(time.Second) / timerFrequency)
 }
 
+// Close stops the audio output
+func (ap *AudioPlayer) Close() error {
+	return ap.sink.Close()
+}
+
+// PlayBuffer plays the given XO-CHIP audio pattern for one timer tick
 func (ap *AudioPlayer) PlayBuffer(buffer [16]byte) {
-	ap.queue.Add(newXOChipBuffer(buffer, ap.sampleRate))
+	if ap.mute {
+		return
+	}
+
+	ap.sink.Lock()
+	defer ap.sink.Unlock()
+	ap.xoChip.Push(buffer, ap.volume*maxAmplitude)
+}
+
+// QueueLatency returns the duration of the XO-CHIP audio waiting to be played
+func (ap *AudioPlayer) QueueLatency() time.Duration {
+	ap.sink.Lock()
+	defer ap.sink.Unlock()
+	return ap.sampleRate.D(ap.xoChip.Latency())
+}
+
+// Volume returns the volume in the range [0, 1]
+func (ap *AudioPlayer) Volume() float64 {
+	return ap.volume
+}
+
+// SetVolume sets the volume in the range [0, 1]
+func (ap *AudioPlayer) SetVolume(volume float64) {
+	ap.volume = math.Max(0, math.Min(1, volume))
+	ap.updateToneVolume()
+}
+
+// Muted returns whether the audio is muted
+func (ap *AudioPlayer) Muted() bool {
+	return ap.mute
+}
+
+// SetMute mutes or unmutes the audio
+func (ap *AudioPlayer) SetMute(mute bool) {
+	ap.mute = mute
+	ap.updateToneVolume()
+}
+
+// Waveform returns the waveform of the tone
+func (ap *AudioPlayer) Waveform() Waveform {
+	return ap.tone.waveform
+}
+
+// SetWaveform sets the waveform of the tone
+func (ap *AudioPlayer) SetWaveform(waveform Waveform) {
+	ap.sink.Lock()
+	defer ap.sink.Unlock()
+	ap.tone.waveform = waveform % waveformCount
+}
+
+// Frequency returns the frequency of the tone in Hz
+func (ap *AudioPlayer) Frequency() float64 {
+	return ap.tone.frequency
+}
+
+// SetFrequency sets the frequency of the tone in Hz
+func (ap *AudioPlayer) SetFrequency(frequency float64) {
+	ap.sink.Lock()
+	defer ap.sink.Unlock()
+	ap.tone.frequency = clampFrequency(frequency)
+}
+
+func (ap *AudioPlayer) updateToneVolume() {
+	ap.sink.Lock()
+	defer ap.sink.Unlock()
+	ap.tone.volume = ap.volume * maxAmplitude
+	if ap.mute {
+		ap.tone.volume = 0
+	}
 }
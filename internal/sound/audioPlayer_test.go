@@ -0,0 +1,115 @@
+package sound
+
+import (
+	"math"
+	"testing"
+
+	"github.com/faiface/beep"
+	"github.com/stretchr/testify/assert"
+)
+
+// testSink streams the samples on request instead of in real time
+type testSink struct {
+	streamer beep.Streamer
+}
+
+func (s *testSink) Play(streamer beep.Streamer) {
+	s.streamer = streamer
+}
+
+func (s *testSink) Lock() {}
+
+func (s *testSink) Unlock() {}
+
+func (s *testSink) BufferSize() int {
+	return samplesPerTick
+}
+
+func (s *testSink) Close() error {
+	return nil
+}
+
+// stream returns the given number of samples
+func (s *testSink) stream(n int) [][2]float64 {
+	samples := make([][2]float64, n)
+	s.streamer.Stream(samples)
+	return samples
+}
+
+// sounding returns the number of samples until the tone is silent
+func sounding(samples [][2]float64) int {
+	for i, sample := range samples {
+		if sample[0] == 0 && sample[1] == 0 {
+			return i
+		}
+	}
+	return len(samples)
+}
+
+// assertGated checks that the tone played at full volume for the given number of samples followed by the fade out
+func assertGated(t *testing.T, samples [][2]float64, n int) {
+	ramp := beep.SampleRate(SampleRate).N(rampDuration)
+	assert.InDelta(t, n+ramp, sounding(samples), 1)
+	if n > 0 {
+		assert.InDelta(t, DefaultVolume*maxAmplitude, math.Abs(samples[n-1][0]), 1e-9)
+		assert.Less(t, math.Abs(samples[n][0]), DefaultVolume*maxAmplitude)
+	}
+}
+
+const samplesPerTick = SampleRate / timerFrequency
+
+func TestSoundTimerGating(t *testing.T) {
+	assert := assert.New(t)
+
+	sink := &testSink{}
+	ap := NewAudioPlayer(sink)
+	assert.Equal(0, sounding(sink.stream(100)))
+
+	// The tone plays for exactly the number of timer ticks and fades out afterwards
+	ap.SetSoundTimer(2)
+	assertGated(t, sink.stream(3*samplesPerTick), 2*samplesPerTick)
+
+	// Lowering the timer mid-tone shortens the tone
+	ap.SetSoundTimer(5)
+	assert.Equal(samplesPerTick, sounding(sink.stream(samplesPerTick)))
+	ap.SetSoundTimer(1)
+	assertGated(t, sink.stream(3*samplesPerTick), samplesPerTick)
+
+	// Setting the same value again restarts the tone
+	ap.SetSoundTimer(2)
+	assert.Equal(samplesPerTick, sounding(sink.stream(samplesPerTick)))
+	ap.SetSoundTimer(2)
+	assertGated(t, sink.stream(3*samplesPerTick), 2*samplesPerTick)
+
+	// Zero stops the tone
+	ap.SetSoundTimer(3)
+	sink.stream(samplesPerTick)
+	ap.SetSoundTimer(0)
+	assertGated(t, sink.stream(samplesPerTick), 0)
+}
+
+func TestTone(t *testing.T) {
+	assert := assert.New(t)
+
+	sink := &testSink{}
+	ap := NewAudioPlayer(sink)
+	ap.SetVolume(1)
+	ap.SetFrequency(1000)
+	ap.SetSoundTimer(10)
+
+	// Skip the fade in, a period of the square wave has 48 samples at 1000Hz
+	samples := sink.stream(2 * samplesPerTick)
+	assert.InDelta(maxAmplitude, samples[samplesPerTick+20][0], 1e-9)
+	assert.InDelta(-maxAmplitude, samples[samplesPerTick+44][0], 1e-9)
+	assert.Equal(samples[samplesPerTick+20][0], samples[samplesPerTick+20][1])
+
+	// The volume is applied and muting silences the tone
+	ap.SetVolume(0.5)
+	samples = sink.stream(samplesPerTick)
+	assert.InDelta(maxAmplitude/2, samples[0][0], 1e-9)
+	ap.SetMute(true)
+	assert.Equal(0, sounding(sink.stream(samplesPerTick)))
+	assert.Equal(SquareWave, ap.Waveform())
+	ap.SetWaveform(TriangleWave)
+	assert.Equal(TriangleWave, ap.Waveform())
+}
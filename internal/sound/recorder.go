@@ -0,0 +1,58 @@
+package sound
+
+import "github.com/faiface/beep"
+
+// Recorder renders the sound of the emulated machine to a 16 bit stereo WAV file, one timer tick at a time.
+// Unlike the real-time output it follows the emulated timer exactly, nothing is written while the emulation is paused,
+// and it's independent of the volume and mute settings.
+type Recorder struct {
+	wav     *wavWriter
+	tone    *tone
+	mix     beep.Streamer
+	xoChip  *xoChipStream
+	samples [][2]float64
+}
+
+// NewRecorder creates the given WAV file
+func NewRecorder(path string) (*Recorder, error) {
+	wav, err := newWavWriter(path)
+	if err != nil {
+		return nil, err
+	}
+
+	sr := beep.SampleRate(SampleRate)
+	xoChip := newXOChipStream(sr, 0)
+	tone := newTone(sr, SquareWave, DefaultFrequency, maxAmplitude)
+	return &Recorder{
+		wav:     wav,
+		tone:    tone,
+		mix:     beep.Mix(xoChip, tone),
+		xoChip:  xoChip,
+		samples: make([][2]float64, int(sr)/timerFrequency),
+	}, nil
+}
+
+// SetTone sets the waveform and the frequency in Hz of the tone
+func (r *Recorder) SetTone(waveform Waveform, frequency float64) {
+	r.tone.waveform = waveform % waveformCount
+	r.tone.frequency = clampFrequency(frequency)
+}
+
+// Tick writes the sound of one timer tick with the given sound timer value before the tick.
+// If an XO-CHIP audio pattern is given, it's played instead of the tone.
+func (r *Recorder) Tick(st byte, pattern *[16]byte) error {
+	r.tone.Gate(0)
+	if st > 0 && pattern != nil {
+		r.xoChip.Push(*pattern, maxAmplitude)
+	} else if st > 0 {
+		r.tone.Gate(len(r.samples))
+	}
+
+	r.mix.Stream(r.samples)
+	return r.wav.write(r.samples)
+}
+
+// Close updates the header and closes the file
+func (r *Recorder) Close() error {
+	return r.wav.close()
+}
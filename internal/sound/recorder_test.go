@@ -0,0 +1,45 @@
+package sound
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorder(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "pich8-go")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "test.wav")
+	r, err := NewRecorder(path)
+	assert.Nil(err)
+	r.SetTone(SquareWave, 1000)
+	pattern := [16]byte{0xFF}
+	assert.Nil(r.Tick(0, nil))
+	assert.Nil(r.Tick(2, nil))
+	assert.Nil(r.Tick(1, &pattern))
+	assert.Nil(r.Close())
+
+	// Every tick has exactly the samples of one frame
+	data, err := ioutil.ReadFile(path)
+	assert.Nil(err)
+	assert.Len(data, 44+3*samplesPerTick*4)
+	sample := func(i int) float64 {
+		return float64(int16(binary.LittleEndian.Uint16(data[44+i*4:]))) / math.MaxInt16
+	}
+
+	// Silence, the tone at full volume and the pattern, which starts with 8 set bits of 12 samples while the tone fades out
+	assert.Equal(0.0, sample(samplesPerTick-1))
+	assert.InDelta(maxAmplitude, math.Abs(sample(samplesPerTick+200)), 0.001)
+	assert.InDelta(maxAmplitude, math.Abs(sample(2*samplesPerTick-1)), 0.001)
+	assert.InDelta(maxAmplitude, sample(2*samplesPerTick+90), 0.02)
+	assert.Equal(0.0, sample(2*samplesPerTick+120))
+}
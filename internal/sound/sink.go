@@ -0,0 +1,235 @@
+package sound
+
+import (
+	"bufio"
+	"encoding/binary"
+	"math"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/faiface/beep"
+)
+
+const (
+	pumpInterval = 10 * time.Millisecond
+)
+
+// Sink outputs the audio stream generated by the AudioPlayer
+type Sink interface {
+	// Play starts streaming the given streamer
+	Play(streamer beep.Streamer)
+	// Lock must be held while modifying the state of the played streamer
+	Lock()
+	// Unlock releases the lock acquired by Lock
+	Unlock()
+	// BufferSize returns the number of samples pulled from the streamer at once
+	BufferSize() int
+	// Close stops the output and releases all resources, further calls return the result of the first one
+	Close() error
+}
+
+// pumpSink pulls the samples from the streamer in real time and passes them on to a write function
+type pumpSink struct {
+	mu       sync.Mutex
+	streamer beep.Streamer
+	write    func(samples [][2]float64) error
+	close    func() error
+	err      error
+
+	done      chan struct{}
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+	closeErr  error
+}
+
+func newPumpSink(write func(samples [][2]float64) error, close func() error) *pumpSink {
+	sink := &pumpSink{
+		write: write,
+		close: close,
+		done:  make(chan struct{}),
+	}
+	sink.wg.Add(1)
+	go sink.run()
+	return sink
+}
+
+// NewNullSink returns a sink which consumes the audio stream in real time without playing it
+func NewNullSink() Sink {
+	return newPumpSink(nil, nil)
+}
+
+func (ps *pumpSink) Play(streamer beep.Streamer) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.streamer = streamer
+}
+
+func (ps *pumpSink) Lock() {
+	ps.mu.Lock()
+}
+
+func (ps *pumpSink) Unlock() {
+	ps.mu.Unlock()
+}
+
+func (ps *pumpSink) BufferSize() int {
+	return beep.SampleRate(SampleRate).N(pumpInterval)
+}
+
+func (ps *pumpSink) Close() error {
+	ps.closeOnce.Do(func() {
+		close(ps.done)
+		ps.wg.Wait()
+
+		ps.closeErr = ps.err
+		if ps.close != nil {
+			if err := ps.close(); ps.closeErr == nil {
+				ps.closeErr = err
+			}
+		}
+	})
+	return ps.closeErr
+}
+
+func (ps *pumpSink) run() {
+	defer ps.wg.Done()
+
+	sr := beep.SampleRate(SampleRate)
+	ticker := time.NewTicker(pumpInterval)
+	defer ticker.Stop()
+
+	buffer := make([][2]float64, ps.BufferSize())
+	start := time.Now()
+	produced := 0
+	for {
+		select {
+		case <-ps.done:
+			return
+		case <-ticker.C:
+			due := sr.N(time.Since(start)) - produced
+			for due > 0 {
+				samples := buffer
+				if due < len(samples) {
+					samples = samples[:due]
+				}
+				ps.stream(samples)
+				if ps.write != nil && ps.err == nil {
+					ps.err = ps.write(samples)
+				}
+				produced += len(samples)
+				due -= len(samples)
+			}
+		}
+	}
+}
+
+func (ps *pumpSink) stream(samples [][2]float64) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	filled := 0
+	if ps.streamer != nil {
+		filled, _ = ps.streamer.Stream(samples)
+	}
+	for i := filled; i < len(samples); i++ {
+		samples[i] = [2]float64{}
+	}
+}
+
+// NewWavSink returns a sink which writes the audio stream in real time to a 16 bit stereo WAV file
+func NewWavSink(path string) (Sink, error) {
+	wav, err := newWavWriter(path)
+	if err != nil {
+		return nil, err
+	}
+	return newPumpSink(wav.write, wav.close), nil
+}
+
+type wavWriter struct {
+	file     *os.File
+	writer   *bufio.Writer
+	dataSize uint32
+	buffer   []byte
+}
+
+// newWavWriter creates the given 16 bit stereo WAV file
+func newWavWriter(path string) (*wavWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	wav := &wavWriter{file: file, writer: bufio.NewWriter(file)}
+	if err := wav.writeHeader(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return wav, nil
+}
+
+func (w *wavWriter) writeHeader() error {
+	const (
+		channels      = 2
+		bitsPerSample = 16
+		blockAlign    = channels * bitsPerSample / 8
+	)
+
+	header := []interface{}{
+		[4]byte{'R', 'I', 'F', 'F'},
+		uint32(36 + w.dataSize),
+		[4]byte{'W', 'A', 'V', 'E'},
+		[4]byte{'f', 'm', 't', ' '},
+		uint32(16), // Chunk size
+		uint16(1),  // PCM
+		uint16(channels),
+		uint32(SampleRate),
+		uint32(SampleRate * blockAlign),
+		uint16(blockAlign),
+		uint16(bitsPerSample),
+		[4]byte{'d', 'a', 't', 'a'},
+		w.dataSize,
+	}
+	for _, field := range header {
+		if err := binary.Write(w.writer, binary.LittleEndian, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *wavWriter) write(samples [][2]float64) error {
+	size := len(samples) * 4
+	if cap(w.buffer) < size {
+		w.buffer = make([]byte, size)
+	}
+	buf := w.buffer[:size]
+
+	for i, sample := range samples {
+		for ch := 0; ch < 2; ch++ {
+			val := int16(math.Max(-1, math.Min(1, sample[ch])) * math.MaxInt16)
+			binary.LittleEndian.PutUint16(buf[i*4+ch*2:], uint16(val))
+		}
+	}
+
+	w.dataSize += uint32(size)
+	_, err := w.writer.Write(buf)
+	return err
+}
+
+// close updates the sizes in the header and closes the file
+func (w *wavWriter) close() error {
+	defer w.file.Close()
+
+	if err := w.writer.Flush(); err != nil {
+		return err
+	}
+	if _, err := w.file.Seek(0, 0); err != nil {
+		return err
+	}
+	w.writer.Reset(w.file)
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	return w.writer.Flush()
+}
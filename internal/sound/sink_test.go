@@ -0,0 +1,64 @@
+package sound
+
+import (
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWavWriter(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "pich8-go")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "test.wav")
+	wav, err := newWavWriter(path)
+	assert.Nil(err)
+	assert.Nil(wav.write([][2]float64{{0, 0}, {1, -1}}))
+	// Samples out of range are clipped
+	assert.Nil(wav.write([][2]float64{{0.5, 2}}))
+	assert.Nil(wav.close())
+
+	data, err := ioutil.ReadFile(path)
+	assert.Nil(err)
+	assert.Len(data, 44+12)
+	assert.Equal("RIFF", string(data[0:4]))
+	assert.Equal(uint32(36+12), binary.LittleEndian.Uint32(data[4:]))
+	assert.Equal("WAVEfmt ", string(data[8:16]))
+	assert.Equal(uint16(2), binary.LittleEndian.Uint16(data[22:]))
+	assert.Equal(uint32(SampleRate), binary.LittleEndian.Uint32(data[24:]))
+	assert.Equal(uint16(16), binary.LittleEndian.Uint16(data[34:]))
+	assert.Equal("data", string(data[36:40]))
+	assert.Equal(uint32(12), binary.LittleEndian.Uint32(data[40:]))
+
+	samples := make([]int16, 6)
+	for i := range samples {
+		samples[i] = int16(binary.LittleEndian.Uint16(data[44+i*2:]))
+	}
+	assert.Equal([]int16{0, 0, 32767, -32767, 16383, 32767}, samples)
+}
+
+func TestPumpSinkClose(t *testing.T) {
+	assert := assert.New(t)
+
+	sink := NewNullSink()
+	assert.Nil(sink.Close())
+	assert.Nil(sink.Close())
+
+	// The output is closed once, further calls return its error
+	closed := 0
+	ps := newPumpSink(nil, func() error {
+		closed++
+		return errors.New("close failed")
+	})
+	assert.EqualError(ps.Close(), "close failed")
+	assert.EqualError(ps.Close(), "close failed")
+	assert.Equal(1, closed)
+}
@@ -0,0 +1,45 @@
+// Package speaker plays the audio of the emulator on the default audio device
+package speaker
+
+import (
+	"time"
+
+	"github.com/faiface/beep"
+	"github.com/faiface/beep/speaker"
+	"github.com/philw07/pich8-go/internal/sound"
+)
+
+// bufferDuration is the duration of the audio buffer of the device
+const bufferDuration = time.Second / 15
+
+// sink plays the audio on the default audio device
+type sink struct{}
+
+// NewSink initializes the default audio device and returns a sink playing on it
+func NewSink() (sound.Sink, error) {
+	if err := speaker.Init(beep.SampleRate(sound.SampleRate), sink{}.BufferSize()); err != nil {
+		return nil, err
+	}
+	return sink{}, nil
+}
+
+func (sink) Play(streamer beep.Streamer) {
+	speaker.Play(streamer)
+}
+
+func (sink) Lock() {
+	speaker.Lock()
+}
+
+func (sink) Unlock() {
+	speaker.Unlock()
+}
+
+func (sink) BufferSize() int {
+	return beep.SampleRate(sound.SampleRate).N(bufferDuration)
+}
+
+func (sink) Close() error {
+	speaker.Clear()
+	return nil
+}
@@ -0,0 +1,123 @@
+package sound
+
+import (
+	"math"
+	"time"
+
+	"github.com/faiface/beep"
+)
+
+// Waveform is the shape of the tone played while the sound timer is active
+type Waveform byte
+
+const (
+	SquareWave Waveform = iota
+	SineWave
+	TriangleWave
+
+	waveformCount = 3
+)
+
+const (
+	// Duration of the fade in and out to avoid clicks
+	rampDuration = 2 * time.Millisecond
+)
+
+func (w Waveform) String() string {
+	switch w {
+	case SineWave:
+		return "Sine"
+	case TriangleWave:
+		return "Triangle"
+	default:
+		return "Square"
+	}
+}
+
+// ParseWaveform returns the waveform with the given name
+func ParseWaveform(name string) (Waveform, bool) {
+	for w := Waveform(0); w < waveformCount; w++ {
+		if w.String() == name {
+			return w, true
+		}
+	}
+	return SquareWave, false
+}
+
+// clampFrequency limits the frequency of the tone to the audible range
+func clampFrequency(frequency float64) float64 {
+	return math.Max(20, math.Min(20000, frequency))
+}
+
+// tone is a streamer generating a phase continuous tone which is gated by a sample counter
+type tone struct {
+	sampleRate beep.SampleRate
+	waveform   Waveform
+	frequency  float64
+	volume     float64
+
+	phase     float64
+	gain      float64
+	rampStep  float64
+	remaining int
+}
+
+func newTone(sampleRate beep.SampleRate, waveform Waveform, frequency, volume float64) *tone {
+	return &tone{
+		sampleRate: sampleRate,
+		waveform:   waveform,
+		frequency:  frequency,
+		volume:     volume,
+		rampStep:   1 / float64(sampleRate.N(rampDuration)),
+	}
+}
+
+// Gate plays the tone for the given number of samples, counted from the next streamed sample
+func (t *tone) Gate(samples int) {
+	t.remaining = samples
+}
+
+func (t *tone) Stream(samples [][2]float64) (int, bool) {
+	step := t.frequency / float64(t.sampleRate)
+	for i := range samples {
+		if t.remaining > 0 {
+			t.remaining--
+			t.gain = math.Min(1, t.gain+t.rampStep)
+		} else {
+			t.gain = math.Max(0, t.gain-t.rampStep)
+		}
+
+		sample := 0.0
+		if t.gain > 0 {
+			sample = t.sample() * t.volume * t.gain
+		}
+		samples[i][0] = sample
+		samples[i][1] = sample
+
+		t.phase += step
+		if t.phase >= 1 {
+			t.phase -= math.Floor(t.phase)
+		}
+	}
+
+	return len(samples), true
+}
+
+func (t *tone) Err() error {
+	return nil
+}
+
+// sample returns the value of the waveform at the current phase in the range [-1, 1]
+func (t *tone) sample() float64 {
+	switch t.waveform {
+	case SineWave:
+		return math.Sin(2 * math.Pi * t.phase)
+	case TriangleWave:
+		return 4*math.Abs(t.phase-0.5) - 1
+	default:
+		if t.phase < 0.5 {
+			return 1
+		}
+		return -1
+	}
+}
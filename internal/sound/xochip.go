@@ -0,0 +1,89 @@
+package sound
+
+import "github.com/faiface/beep"
+
+const (
+	patternFrequency = 4000
+	patternBits      = 128
+
+	// Minimum latency in timer ticks, it's raised for sinks pulling larger buffers
+	minLatencyTicks = 3
+)
+
+// xoChipStream is a streamer playing the XO-CHIP audio pattern.
+// The samples are rendered into a fixed capacity ring once per timer tick,
+// if the audio output lags behind the emulated timer the oldest samples are dropped.
+// The capacity holds at least one buffer of the sink plus one tick, so the ticks pushed between two pulls aren't dropped.
+type xoChipStream struct {
+	ring           []float64
+	head           int
+	size           int
+	samplesPerTick int
+	maxLatency     int
+
+	pos  float64
+	step float64
+}
+
+func newXOChipStream(sampleRate beep.SampleRate, bufferSize int) *xoChipStream {
+	samplesPerTick := int(sampleRate) / timerFrequency
+	latencyTicks := (bufferSize+samplesPerTick-1)/samplesPerTick + 1
+	if latencyTicks < minLatencyTicks {
+		latencyTicks = minLatencyTicks
+	}
+	return &xoChipStream{
+		ring:           make([]float64, latencyTicks*samplesPerTick),
+		samplesPerTick: samplesPerTick,
+		maxLatency:     latencyTicks * samplesPerTick,
+		step:           patternFrequency / float64(sampleRate),
+	}
+}
+
+// Push renders one timer tick of the given pattern into the ring
+func (xs *xoChipStream) Push(pattern [16]byte, volume float64) {
+	// Correct drift by dropping the oldest samples
+	if excess := xs.size + xs.samplesPerTick - xs.maxLatency; excess > 0 {
+		xs.head = (xs.head + excess) % len(xs.ring)
+		xs.size -= excess
+	}
+
+	for i := 0; i < xs.samplesPerTick; i++ {
+		bit := int(xs.pos)
+		val := 0.0
+		if pattern[bit/8]>>(7-bit%8)&1 == 1 {
+			val = volume
+		}
+		xs.ring[(xs.head+xs.size)%len(xs.ring)] = val
+		xs.size++
+
+		// The position in the pattern continues across ticks
+		xs.pos += xs.step
+		if xs.pos >= patternBits {
+			xs.pos -= patternBits
+		}
+	}
+}
+
+// Latency returns the number of buffered samples
+func (xs *xoChipStream) Latency() int {
+	return xs.size
+}
+
+func (xs *xoChipStream) Stream(samples [][2]float64) (int, bool) {
+	for i := range samples {
+		val := 0.0
+		if xs.size > 0 {
+			val = xs.ring[xs.head]
+			xs.head = (xs.head + 1) % len(xs.ring)
+			xs.size--
+		}
+		samples[i][0] = val
+		samples[i][1] = val
+	}
+
+	return len(samples), true
+}
+
+func (xs *xoChipStream) Err() error {
+	return nil
+}
@@ -0,0 +1,72 @@
+package sound
+
+import (
+	"testing"
+
+	"github.com/faiface/beep"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestXOChipStreamPattern(t *testing.T) {
+	assert := assert.New(t)
+
+	xs := newXOChipStream(beep.SampleRate(SampleRate), 0)
+	pattern := [16]byte{0x80}
+	pattern[15] = 0x01
+	xs.Push(pattern, 0.5)
+	assert.Equal(samplesPerTick, xs.Latency())
+
+	// Every bit lasts 12 samples at 4000 bits per second
+	samples := make([][2]float64, samplesPerTick)
+	n, ok := xs.Stream(samples)
+	assert.Equal(samplesPerTick, n)
+	assert.True(ok)
+	assert.Equal([2]float64{0.5, 0.5}, samples[11])
+	assert.Equal([2]float64{0, 0}, samples[12])
+
+	// The position continues across ticks, the last bit starts at sample 1524 of the pattern
+	xs.Push(pattern, 0.5)
+	xs.Stream(samples)
+	assert.Equal([2]float64{0, 0}, samples[1520-samplesPerTick])
+	assert.Equal([2]float64{0.5, 0.5}, samples[1526-samplesPerTick])
+
+	// Nothing is queued, silence is played
+	samples[0] = [2]float64{1, 1}
+	xs.Stream(samples)
+	assert.Equal([2]float64{0, 0}, samples[0])
+}
+
+func TestXOChipStreamLatency(t *testing.T) {
+	assert := assert.New(t)
+
+	// A sink pulling 4 ticks at once, like the speaker
+	bufferSize := 4 * samplesPerTick
+	xs := newXOChipStream(beep.SampleRate(SampleRate), bufferSize)
+	pattern := [16]byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}
+	samples := make([][2]float64, bufferSize)
+
+	// No samples are dropped or padded with silence, even if an extra tick arrives between two pulls
+	for _, ticks := range []int{4, 5, 3, 4} {
+		queued := xs.Latency()
+		for i := 0; i < ticks; i++ {
+			xs.Push(pattern, 1)
+		}
+		assert.Equal(queued+ticks*samplesPerTick, xs.Latency())
+		xs.Stream(samples)
+		assert.Equal(bufferSize, sounding(samples))
+	}
+	assert.Equal(0, xs.Latency())
+
+	// If the output lags behind, the oldest samples are dropped
+	for i := 0; i < 10; i++ {
+		xs.Push(pattern, 1)
+	}
+	assert.Equal(bufferSize+samplesPerTick, xs.Latency())
+
+	// Sinks with small buffers keep the minimum latency
+	xs = newXOChipStream(beep.SampleRate(SampleRate), 480)
+	for i := 0; i < 10; i++ {
+		xs.Push(pattern, 1)
+	}
+	assert.Equal(minLatencyTicks*samplesPerTick, xs.Latency())
+}
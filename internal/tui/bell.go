@@ -0,0 +1,53 @@
+package tui
+
+import (
+	"time"
+
+	"github.com/philw07/pich8-go/internal/emulator"
+)
+
+// tick is the period of the timers
+const tick = time.Second / 60
+
+// Bell rings the terminal bell when the sound starts and passes the sound on to the embedded audio,
+// which keeps the audio settings like with any other output
+type Bell struct {
+	emulator.Audio
+	term *Terminal
+
+	// The bell doesn't ring again while the previous sound lasts
+	until time.Time
+}
+
+// NewBell creates a bell ringing on the given terminal in addition to the given audio
+func NewBell(term *Terminal, audio emulator.Audio) *Bell {
+	return &Bell{Audio: audio, term: term}
+}
+
+// SetSoundTimer rings the bell if the sound starts
+func (b *Bell) SetSoundTimer(st byte) {
+	if st == 0 {
+		// The ROM stopped the sound
+		b.until = time.Time{}
+	}
+	b.ring(time.Duration(st) * tick)
+	b.Audio.SetSoundTimer(st)
+}
+
+// PlayBuffer rings the bell if the XO-CHIP sound starts
+func (b *Bell) PlayBuffer(buffer [16]byte) {
+	b.ring(tick)
+	b.Audio.PlayBuffer(buffer)
+}
+
+func (b *Bell) ring(duration time.Duration) {
+	if duration == 0 || b.Muted() {
+		return
+	}
+	now := time.Now()
+	if !now.Before(b.until) {
+		b.term.Bell()
+	}
+	// The ticks of the timers aren't scheduled exactly
+	b.until = now.Add(duration + tick)
+}
@@ -0,0 +1,222 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/philw07/pich8-go/internal/config"
+	"github.com/philw07/pich8-go/internal/emulator"
+	"github.com/philw07/pich8-go/internal/input"
+)
+
+const (
+	// The frames are drawn at most at this rate, the main loop of the emulator sleeps in between
+	frameRate = 60
+	// Notifications are shown this long in the status line
+	notificationDuration = 2 * time.Second
+)
+
+// quitKey always quits, as the terminal doesn't send a signal in raw mode
+var quitKey = input.Binding{Key: "C", Ctrl: true}
+
+// terminalInput is data read from the terminal with the time it was received
+type terminalInput struct {
+	data []byte
+	time time.Time
+}
+
+// Frontend shows the emulator in the terminal and reads the keys from it, it implements the display and the input of the emulator
+type Frontend struct {
+	term     *Terminal
+	renderer Renderer
+	name     string
+	inputs   chan terminalInput
+	closed   bool
+
+	// Input
+	keys        KeyState
+	events      []emulator.KeyEvent
+	justPressed map[input.Binding]bool
+
+	// Status line
+	title            string
+	notification     string
+	notificationTime time.Time
+	err              error
+
+	lastFrame, lastStatus string
+	lastSize              [2]int
+	nextFrame             time.Time
+}
+
+// NewFrontend creates a frontend drawing with the given mode to the terminal,
+// the name is shown in the status line if the ROM has no title
+func NewFrontend(term *Terminal, mode Mode, name string) *Frontend {
+	f := &Frontend{
+		term:        term,
+		renderer:    Renderer{Mode: mode},
+		name:        name,
+		inputs:      make(chan terminalInput, 16),
+		justPressed: map[input.Binding]bool{},
+	}
+	go f.read()
+	return f
+}
+
+// read reads the terminal input in the background, the channel is closed at the end of the input
+func (f *Frontend) read() {
+	buf := make([]byte, 64)
+	for {
+		n, err := f.term.in.Read(buf)
+		if err != nil {
+			close(f.inputs)
+			return
+		}
+		data := make([]byte, n)
+		copy(data, buf[:n])
+		f.inputs <- terminalInput{data, time.Now()}
+	}
+}
+
+// Draw draws the frame and the status line if they changed and waits for the next frame
+func (f *Frontend) Draw(frame *emulator.Frame) {
+	vmem := &frame.Vmem
+	if size := [2]int{vmem.RenderWidth(), vmem.RenderHeight()}; size != f.lastSize {
+		// The resolution changed
+		f.term.Clear()
+		f.lastSize = size
+		f.lastFrame, f.lastStatus = "", ""
+	}
+
+	f.renderer.Palette = frame.Palette
+	if out := f.renderer.Render(vmem); out != f.lastFrame {
+		fmt.Fprint(f.term.out, out)
+		f.lastFrame = out
+	}
+	if status := f.status(frame); status != f.lastStatus {
+		fmt.Fprintf(f.term.out, "\x1b[%v;1H\x1b[0m\x1b[2K%v", f.renderer.Rows(vmem)+1, status)
+		f.lastStatus = status
+	}
+
+	// The terminal has no vertical sync
+	now := time.Now()
+	if f.nextFrame.Before(now) {
+		f.nextFrame = now
+	}
+	time.Sleep(f.nextFrame.Sub(now))
+	f.nextFrame = f.nextFrame.Add(time.Second / frameRate)
+}
+
+// status returns the status line shown below the frame
+func (f *Frontend) status(frame *emulator.Frame) string {
+	status := f.name
+	if f.title != "" {
+		status = f.title
+	}
+	if frame.Paused {
+		status += " [paused]"
+	}
+	switch {
+	case f.err != nil:
+		status += fmt.Sprintf(" - Error: %v", f.err)
+	case time.Since(f.notificationTime) <= notificationDuration:
+		status += " - " + f.notification
+	}
+	return status + " - Esc or Ctrl + C quits"
+}
+
+// DisplayNotification shows the given text in the status line for a short time
+func (f *Frontend) DisplayNotification(text string) {
+	f.notification = text
+	f.notificationTime = time.Now()
+	f.err = nil
+}
+
+// SetTitle shows the given title in the status line instead of the name
+func (f *Frontend) SetTitle(title string) {
+	f.title = title
+}
+
+// Reset redraws the whole screen with the next frame
+func (f *Frontend) Reset() {
+	f.lastSize = [2]int{}
+	f.err = nil
+}
+
+// PerformAction ignores the commands concerning the window, as the terminal has none of its features
+func (f *Frontend) PerformAction(action input.Action) {}
+
+// UpdateSettings keeps the settings, the terminal has no display settings
+func (f *Frontend) UpdateSettings(settings *config.Settings) {}
+
+// OpenFile returns false, the ROM is only given on the command line
+func (f *Frontend) OpenFile(title, dir string) (string, bool) {
+	f.DisplayNotification("Opening files isn't available in the terminal")
+	return "", false
+}
+
+// ShowError shows the given error in the status line until the next notification
+func (f *Frontend) ShowError(err error) {
+	f.err = err
+}
+
+// Closed returns whether Ctrl + C was pressed or the input ended
+func (f *Frontend) Closed() bool {
+	return f.closed
+}
+
+// KnownKey returns true, the terminal has no fixed set of keys and other keys are never received
+func (f *Frontend) KnownKey(key string) bool {
+	return true
+}
+
+// KeyEvents returns the key presses read since the previous call and the releases derived from them
+func (f *Frontend) KeyEvents() []emulator.KeyEvent {
+	// KeyEvents is called once at the start of every frame
+	f.justPressed = map[input.Binding]bool{}
+
+	for more := true; more; {
+		select {
+		case in, ok := <-f.inputs:
+			if !ok {
+				f.closed = true
+				more = false
+				break
+			}
+			for _, key := range Decode(in.data) {
+				f.press(key, in.time)
+			}
+		default:
+			more = false
+		}
+	}
+	f.events = append(f.events, f.keys.Release(time.Now())...)
+
+	events := f.events
+	f.events = nil
+	return events
+}
+
+func (f *Frontend) press(key input.Binding, t time.Time) {
+	if key == quitKey {
+		f.closed = true
+		return
+	}
+	f.justPressed[key] = true
+
+	// The keys released in the meantime precede the press
+	f.events = append(f.events, f.keys.Release(t)...)
+	if f.keys.Press(key.Key, t) {
+		f.events = append(f.events, emulator.KeyEvent{Key: key.Key, Pressed: true, Time: t})
+	}
+}
+
+// JustPressed returns whether the given binding was read since the previous frame, the terminal reports the keys with their modifier
+func (f *Frontend) JustPressed(binding input.Binding) bool {
+	return f.justPressed[binding]
+}
+
+// KeypadKey returns false, there's no virtual keypad in the terminal
+func (f *Frontend) KeypadKey() (byte, bool) {
+	return 0, false
+}
@@ -0,0 +1,133 @@
+package tui
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/philw07/pich8-go/internal/emulator"
+	"github.com/philw07/pich8-go/internal/input"
+)
+
+const (
+	// A key press is held this long, which bridges the delay until the terminal repeats a held key
+	holdFirst = 500 * time.Millisecond
+	// A repeated key press extends the hold by this duration
+	holdRepeat = 100 * time.Millisecond
+)
+
+// escapeSequences maps the escape sequences of the special keys to the key names of the window library
+var escapeSequences = map[string]string{
+	"[A": "Up", "[B": "Down", "[C": "Right", "[D": "Left",
+	"OA": "Up", "OB": "Down", "OC": "Right", "OD": "Left",
+	"[H": "Home", "[F": "End", "[2~": "Insert", "[3~": "Delete",
+	"[5~": "PageUp", "[6~": "PageDown",
+	"OP": "F1", "OQ": "F2", "OR": "F3", "OS": "F4",
+	"[15~": "F5", "[17~": "F6", "[18~": "F7", "[19~": "F8",
+	"[20~": "F9", "[21~": "F10", "[23~": "F11", "[24~": "F12",
+}
+
+// keyNames maps the characters which aren't named by themselves
+var keyNames = map[byte]string{
+	' ': "Space", '\r': "Enter", '\n': "Enter", '\t': "Tab", 0x7f: "Backspace",
+	'-': "Minus", '=': "Equal", ',': "Comma", '.': "Period", '/': "Slash",
+	';': "Semicolon", '\'': "Apostrophe", '[': "LeftBracket", ']': "RightBracket", '\\': "Backslash",
+}
+
+// Decode returns the keys contained in the given terminal input, unknown escape sequences are skipped
+func Decode(data []byte) []input.Binding {
+	var keys []input.Binding
+	for len(data) > 0 {
+		b := data[0]
+		data = data[1:]
+		switch {
+		case b == 0x1b:
+			if len(data) == 0 {
+				keys = append(keys, input.Binding{Key: "Escape"})
+				continue
+			}
+			seq, key, n := decodeEscape(data)
+			data = data[n:]
+			if key != "" {
+				keys = append(keys, input.Binding{Key: key, Ctrl: strings.Contains(seq, ";5")})
+			}
+		case b >= 'a' && b <= 'z':
+			keys = append(keys, input.Binding{Key: string(b - 'a' + 'A')})
+		case b >= 'A' && b <= 'Z', b >= '0' && b <= '9':
+			keys = append(keys, input.Binding{Key: string(b)})
+		case keyNames[b] != "":
+			keys = append(keys, input.Binding{Key: keyNames[b]})
+		case b >= 1 && b <= 26:
+			keys = append(keys, input.Binding{Key: string(b - 1 + 'A'), Ctrl: true})
+		}
+	}
+	return keys
+}
+
+// decodeEscape decodes the escape sequence at the start of the data, which follows an escape character.
+// It returns the sequence, the key name if known and the length of the sequence.
+func decodeEscape(data []byte) (string, string, int) {
+	if data[0] != '[' && data[0] != 'O' {
+		// Alt + key, the key is decoded on its own
+		return "", "", 0
+	}
+
+	// The sequence ends with a letter or a tilde
+	n := 1
+	for n < len(data) && !(data[n] >= 'A' && data[n] <= 'Z' || data[n] >= 'a' && data[n] <= 'z' || data[n] == '~') {
+		n++
+	}
+	if n == len(data) {
+		return "", "", n
+	}
+	n++
+	seq := string(data[:n])
+
+	// Modifiers like "[1;5A" or "[15;5~"
+	name := seq
+	if i := strings.Index(seq, ";"); i >= 0 {
+		name = seq[:i] + seq[n-1:]
+		if name == "[1A" || name == "[1B" || name == "[1C" || name == "[1D" || name == "[1H" || name == "[1F" {
+			name = "[" + name[2:]
+		}
+	}
+	return seq, escapeSequences[name], n
+}
+
+// KeyState derives the presses and releases of the keys from the terminal input,
+// as terminals only send a key when it's pressed and repeat it while it's held down
+type KeyState struct {
+	until map[string]time.Time
+}
+
+// Press presses the given key at the given time, returns false if the key was already held down
+func (ks *KeyState) Press(key string, t time.Time) bool {
+	if ks.until == nil {
+		ks.until = map[string]time.Time{}
+	}
+	if until, ok := ks.until[key]; ok && until.After(t) {
+		// The terminal repeats the held key
+		if repeat := t.Add(holdRepeat); repeat.After(until) {
+			ks.until[key] = repeat
+		}
+		return false
+	}
+	ks.until[key] = t.Add(holdFirst)
+	return true
+}
+
+// Release releases the keys whose hold ended at the given time,
+// returns the releases at the times the holds ended in chronological order
+func (ks *KeyState) Release(t time.Time) []emulator.KeyEvent {
+	var events []emulator.KeyEvent
+	for key, until := range ks.until {
+		if !until.After(t) {
+			events = append(events, emulator.KeyEvent{Key: key, Time: until})
+			delete(ks.until, key)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+	return events
+}
@@ -0,0 +1,132 @@
+// Package tui runs the emulator in a terminal, the frames are drawn with Unicode block or braille characters
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/philw07/pich8-go/internal/palette"
+	"github.com/philw07/pich8-go/internal/videomemory"
+)
+
+// Mode determines the characters the frame is drawn with
+type Mode int
+
+const (
+	// HalfBlock draws two pixels above each other per character in their own colors
+	HalfBlock Mode = iota
+	// Braille draws 2x4 pixels per character, the pixels of a character share one color
+	Braille
+)
+
+// Modes contains all modes
+var Modes = [...]Mode{HalfBlock, Braille}
+
+var modeNames = [...]string{"halfblock", "braille"}
+
+func (m Mode) String() string {
+	if m < 0 || int(m) >= len(modeNames) {
+		return "invalid"
+	}
+	return modeNames[m]
+}
+
+// ParseMode parses the name of a mode
+func ParseMode(s string) (Mode, error) {
+	for _, m := range Modes {
+		if m.String() == s {
+			return m, nil
+		}
+	}
+	return HalfBlock, fmt.Errorf("unknown terminal mode %q", s)
+}
+
+// brailleDots are the bits of the braille dots by their position in the 2x4 cell
+var brailleDots = [4][2]rune{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+// Renderer draws the VideoMemory with ANSI escape sequences, only color changes are written
+type Renderer struct {
+	Mode    Mode
+	Palette palette.Palette
+
+	sb     strings.Builder
+	fg, bg int
+}
+
+// Render returns the escape sequences which draw the visible content of the VideoMemory to the top left corner of the terminal
+func (r *Renderer) Render(vmem *videomemory.VideoMemory) string {
+	r.sb.Reset()
+	r.fg, r.bg = -1, -1
+
+	// The doubled low resolution pixels are drawn once
+	size := vmem.RenderWidth() / vmem.Width()
+	w, h := vmem.RenderWidth()/size, vmem.RenderHeight()/size
+	index := func(x, y int) int {
+		return palette.Index(vmem, vmem.ToIndex(x*size, y*size))
+	}
+
+	switch r.Mode {
+	case Braille:
+		for row := 0; row < h/4; row++ {
+			fmt.Fprintf(&r.sb, "\x1b[%v;1H", row+1)
+			for col := 0; col < w/2; col++ {
+				var counts [4]int
+				char := rune(0x2800)
+				for dy := 0; dy < 4; dy++ {
+					for dx := 0; dx < 2; dx++ {
+						if idx := index(col*2+dx, row*4+dy); idx != 0 {
+							char |= brailleDots[dy][dx]
+							counts[idx]++
+						}
+					}
+				}
+				fg := 1
+				for idx := 2; idx < len(counts); idx++ {
+					if counts[idx] > counts[fg] {
+						fg = idx
+					}
+				}
+				r.setColors(fg, 0)
+				r.sb.WriteRune(char)
+			}
+		}
+	default:
+		for row := 0; row < h/2; row++ {
+			fmt.Fprintf(&r.sb, "\x1b[%v;1H", row+1)
+			for x := 0; x < w; x++ {
+				r.setColors(index(x, row*2), index(x, row*2+1))
+				r.sb.WriteRune('▀')
+			}
+		}
+	}
+	r.sb.WriteString("\x1b[0m")
+	return r.sb.String()
+}
+
+// Rows returns the number of terminal rows the VideoMemory is drawn to
+func (r *Renderer) Rows(vmem *videomemory.VideoMemory) int {
+	h := vmem.RenderHeight() / (vmem.RenderWidth() / vmem.Width())
+	if r.Mode == Braille {
+		return h / 4
+	}
+	return h / 2
+}
+
+// setColors writes the escape sequences for the given palette indices if they differ from the current colors
+func (r *Renderer) setColors(fg, bg int) {
+	if fg != r.fg {
+		c := r.Palette[fg]
+		fmt.Fprintf(&r.sb, "\x1b[38;2;%v;%v;%vm", c.R, c.G, c.B)
+		r.fg = fg
+	}
+	if bg != r.bg {
+		c := r.Palette[bg]
+		fmt.Fprintf(&r.sb, "\x1b[48;2;%v;%v;%vm", c.R, c.G, c.B)
+		r.bg = bg
+	}
+}
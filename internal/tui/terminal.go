@@ -0,0 +1,57 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Terminal is a terminal in raw mode showing the alternate screen
+type Terminal struct {
+	in    *os.File
+	out   io.Writer
+	state string
+}
+
+// Open switches the terminal of the given input to raw mode and shows the alternate screen on the given output.
+// The mode is changed with stty, which is available on Linux, macOS and the BSDs.
+func Open(in *os.File, out io.Writer) (*Terminal, error) {
+	state, err := stty(in, "-g")
+	if err != nil {
+		return nil, fmt.Errorf("no terminal or stty unavailable: %v", err)
+	}
+	if _, err := stty(in, "raw", "-echo"); err != nil {
+		return nil, err
+	}
+
+	t := &Terminal{in: in, out: out, state: strings.TrimSpace(state)}
+	// Alternate screen, hide the cursor and clear the screen
+	fmt.Fprint(out, "\x1b[?1049h\x1b[?25l\x1b[2J")
+	return t, nil
+}
+
+// Clear clears the screen
+func (t *Terminal) Clear() {
+	fmt.Fprint(t.out, "\x1b[0m\x1b[2J")
+}
+
+// Bell rings the terminal bell
+func (t *Terminal) Bell() {
+	fmt.Fprint(t.out, "\a")
+}
+
+// Close restores the previous mode and screen of the terminal
+func (t *Terminal) Close() error {
+	fmt.Fprint(t.out, "\x1b[0m\x1b[?25h\x1b[?1049l")
+	_, err := stty(t.in, t.state)
+	return err
+}
+
+func stty(in *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = in
+	out, err := cmd.Output()
+	return string(out), err
+}
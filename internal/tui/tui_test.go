@@ -0,0 +1,149 @@
+package tui
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/philw07/pich8-go/internal/emulator"
+	"github.com/philw07/pich8-go/internal/input"
+	"github.com/philw07/pich8-go/internal/palette"
+	"github.com/philw07/pich8-go/internal/videomemory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMode(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, m := range Modes {
+		parsed, err := ParseMode(m.String())
+		assert.Nil(err)
+		assert.Equal(m, parsed)
+	}
+	_, err := ParseMode("sixel")
+	assert.NotNil(err)
+}
+
+func TestRenderHalfBlock(t *testing.T) {
+	assert := assert.New(t)
+
+	vmem := videomemory.NewVideoMemory()
+	vmem.Set(videomemory.FirstPlane, 0, 0, true)
+	vmem.Set(videomemory.SecondPlane, 1, 1, true)
+
+	r := Renderer{Mode: HalfBlock, Palette: palette.Default}
+	out := r.Render(vmem)
+	assert.Equal(16, r.Rows(vmem))
+
+	// One character per low resolution pixel column and two rows per character
+	assert.Equal(64*16, strings.Count(out, "▀"))
+	assert.True(strings.HasPrefix(out, "\x1b[1;1H\x1b[38;2;255;255;255m\x1b[48;2;0;0;0m▀"))
+	// The second character has the background on top and the second plane below
+	assert.Contains(out, "▀\x1b[38;2;0;0;0m\x1b[48;2;168;168;168m▀\x1b[48;2;0;0;0m▀")
+	assert.True(strings.HasSuffix(out, "\x1b[0m"))
+}
+
+func TestRenderBraille(t *testing.T) {
+	assert := assert.New(t)
+
+	vmem := videomemory.NewVideoMemory()
+	vmem.Set(videomemory.FirstPlane, 0, 0, true)
+	vmem.Set(videomemory.FirstPlane, 1, 3, true)
+
+	r := Renderer{Mode: Braille, Palette: palette.Default}
+	out := r.Render(vmem)
+	assert.Equal(8, r.Rows(vmem))
+	assert.True(strings.HasPrefix(out, "\x1b[1;1H\x1b[38;2;255;255;255m\x1b[48;2;0;0;0m⢁⠀"))
+	assert.Equal(8, strings.Count(out, ";1H"))
+}
+
+func TestDecode(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal([]input.Binding{{Key: "Q"}, {Key: "1"}, {Key: "Space"}, {Key: "Q"}}, Decode([]byte("q1 Q")))
+	assert.Equal([]input.Binding{{Key: "C", Ctrl: true}}, Decode([]byte{3}))
+	assert.Equal([]input.Binding{{Key: "Escape"}}, Decode([]byte{0x1b}))
+	assert.Equal([]input.Binding{{Key: "Up"}, {Key: "Left"}, {Key: "F1"}, {Key: "F5"}},
+		Decode([]byte("\x1b[A\x1bOD\x1bOP\x1b[15~")))
+	assert.Equal([]input.Binding{{Key: "F5", Ctrl: true}, {Key: "Right", Ctrl: true}},
+		Decode([]byte("\x1b[15;5~\x1b[1;5C")))
+
+	// Unknown sequences are skipped
+	assert.Equal([]input.Binding{{Key: "W"}}, Decode([]byte("\x1b[99~w")))
+}
+
+func TestKeyState(t *testing.T) {
+	assert := assert.New(t)
+
+	var ks KeyState
+	start := time.Now()
+	assert.True(ks.Press("W", start))
+	assert.Empty(ks.Release(start.Add(holdFirst - time.Millisecond)))
+	assert.Equal([]emulator.KeyEvent{{Key: "W", Time: start.Add(holdFirst)}}, ks.Release(start.Add(holdFirst)))
+
+	// The repeated key is released shortly after the last repetition
+	assert.True(ks.Press("W", start))
+	assert.True(ks.Press("S", start.Add(time.Millisecond)))
+	assert.False(ks.Press("W", start.Add(holdFirst-time.Millisecond)))
+	last := start.Add(holdFirst + 50*time.Millisecond)
+	assert.False(ks.Press("W", last))
+	assert.Equal([]emulator.KeyEvent{{Key: "S", Time: start.Add(holdFirst + time.Millisecond)}}, ks.Release(last))
+	assert.Empty(ks.Release(last.Add(holdRepeat - time.Millisecond)))
+	assert.Equal([]emulator.KeyEvent{{Key: "W", Time: last.Add(holdRepeat)}}, ks.Release(last.Add(time.Second)))
+}
+
+func TestFrontendKeys(t *testing.T) {
+	assert := assert.New(t)
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	var out strings.Builder
+	f := NewFrontend(&Terminal{in: r, out: &out}, HalfBlock, "rom.ch8")
+
+	// keyEvents waits until the written input was read
+	keyEvents := func(data string) []emulator.KeyEvent {
+		w.Write([]byte(data))
+		for len(f.inputs) == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		return f.KeyEvents()
+	}
+
+	// The terminal reports the keys with their modifier and the held keys only by repetition
+	events := keyEvents("w\x0f")
+	if assert.Len(events, 2) {
+		assert.Equal("W", events[0].Key)
+		assert.True(events[0].Pressed)
+		assert.Equal("O", events[1].Key)
+	}
+	assert.True(f.JustPressed(input.Binding{Key: "W"}))
+	assert.True(f.JustPressed(input.Binding{Key: "O", Ctrl: true}))
+	assert.False(f.JustPressed(input.Binding{Key: "O"}))
+	assert.Empty(keyEvents("w"))
+	assert.True(f.JustPressed(input.Binding{Key: "W"}))
+	assert.Empty(f.KeyEvents())
+	assert.False(f.JustPressed(input.Binding{Key: "W"}))
+
+	// The keys are released when they're no longer repeated
+	f.keys.until["W"] = time.Now()
+	events = f.KeyEvents()
+	if assert.Len(events, 1) {
+		assert.Equal("W", events[0].Key)
+		assert.False(events[0].Pressed)
+	}
+
+	// Ctrl + C and the end of the input close the frontend
+	assert.False(f.Closed())
+	keyEvents("\x03")
+	assert.True(f.Closed())
+	f.closed = false
+	w.Close()
+	for !f.Closed() {
+		f.KeyEvents()
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,168 @@
+package video
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// overlayCell is the minimum size of a CHIP-8 pixel when the scanlines and the pixel grid are drawn,
+// smaller pixels are enlarged first
+const overlayCell = 4
+
+// Filters contains the strength of the post-processing filters, each between 0 (off) and 1
+type Filters struct {
+	// Persistence blends the previous frames into the current one like the afterglow of a CRT phosphor,
+	// which reduces the flicker of sprites that are erased and redrawn
+	Persistence float64 `json:"persistence"`
+	// Scanlines darkens the bottom line of every row of CHIP-8 pixels
+	Scanlines float64 `json:"scanlines"`
+	// Grid darkens the border of every CHIP-8 pixel
+	Grid float64 `json:"grid"`
+}
+
+// DefaultFilters are used when the filters are switched on without any configured
+var DefaultFilters = Filters{Persistence: 0.5, Scanlines: 0.25}
+
+// Enabled returns whether any filter is enabled
+func (f Filters) Enabled() bool {
+	return f.Persistence > 0 || f.Scanlines > 0 || f.Grid > 0
+}
+
+// Validate returns an error if a filter strength is out of range
+func (f Filters) Validate() error {
+	for _, v := range []struct {
+		name  string
+		value float64
+	}{{"persistence", f.Persistence}, {"scanlines", f.Scanlines}, {"grid", f.Grid}} {
+		if v.value < 0 || v.value > 1 {
+			return fmt.Errorf("invalid %v strength %v, must be between 0 and 1", v.name, v.value)
+		}
+	}
+	// Full persistence would never show a new frame
+	if f.Persistence == 1 {
+		return fmt.Errorf("invalid persistence strength 1, must be less than 1")
+	}
+	return nil
+}
+
+// PostProcessor applies the scaler and the filters to the rendered frames,
+// it keeps the state needed for the persistence between frames
+type PostProcessor struct {
+	Scaler  Scaler
+	Filters Filters
+
+	// Accumulated color channels of the previous frames
+	blend    []float32
+	blendImg *image.RGBA
+	scaled   *image.RGBA
+	out      *image.RGBA
+}
+
+// Reset discards the previous frames, e.g. after loading another ROM
+func (pp *PostProcessor) Reset() {
+	pp.blend = nil
+}
+
+// Process applies the scaler and the enabled filters to the given frame, pixelSize is the number of frame pixels per CHIP-8 pixel.
+// ticks is the number of emulated frames (timer ticks) since the previous call, the persistence decays once per tick
+// independent of how often the window is redrawn.
+// The returned image is either the frame itself or an image owned by the PostProcessor, which is reused by the next call.
+func (pp *PostProcessor) Process(frame *image.RGBA, pixelSize, ticks int) *image.RGBA {
+	if !pp.Filters.Enabled() && pp.Scaler == NoScaler {
+		pp.blend = nil
+		return frame
+	}
+
+	img := frame
+	if pp.Scaler != NoScaler {
+		pp.scaled = pp.Scaler.Scale(frame, pixelSize, pp.scaled)
+		img = pp.scaled
+		pixelSize = pp.Scaler.Factor()
+	}
+	if pp.Filters.Persistence > 0 {
+		img = pp.persist(img, ticks)
+	} else {
+		pp.blend = nil
+	}
+	if pp.Filters.Scanlines > 0 || pp.Filters.Grid > 0 {
+		img = pp.overlay(img, pixelSize)
+	}
+	return img
+}
+
+// persist blends the frame into the accumulated previous frames once per tick,
+// without a tick the previous result is shown until the emulated frame is complete
+func (pp *PostProcessor) persist(frame *image.RGBA, ticks int) *image.RGBA {
+	w, h := frame.Rect.Dx(), frame.Rect.Dy()
+	if pp.blendImg == nil || pp.blendImg.Rect != frame.Rect {
+		pp.blendImg = image.NewRGBA(frame.Rect)
+		pp.blend = nil
+	}
+
+	// Start from the current frame after a reset or when the resolution changed
+	if len(pp.blend) != w*h*3 {
+		pp.blend = make([]float32, w*h*3)
+		for i := range pp.blend {
+			pp.blend[i] = float32(frame.Pix[i/3*4+i%3])
+		}
+	} else if ticks <= 0 {
+		return pp.blendImg
+	}
+
+	p := float32(math.Pow(pp.Filters.Persistence, float64(ticks)))
+	for i := 0; i < w*h; i++ {
+		for c := 0; c < 3; c++ {
+			cur := float32(frame.Pix[i*4+c])
+			acc := cur + (pp.blend[i*3+c]-cur)*p
+			pp.blend[i*3+c] = acc
+			pp.blendImg.Pix[i*4+c] = uint8(acc + 0.5)
+		}
+		pp.blendImg.Pix[i*4+3] = frame.Pix[i*4+3]
+	}
+	return pp.blendImg
+}
+
+// overlay enlarges the frame and darkens the scanlines and the pixel grid
+func (pp *PostProcessor) overlay(frame *image.RGBA, pixelSize int) *image.RGBA {
+	if pixelSize < 1 {
+		pixelSize = 1
+	}
+	scale := (overlayCell + pixelSize - 1) / pixelSize
+	w, h := frame.Rect.Dx(), frame.Rect.Dy()
+	rect := image.Rect(0, 0, w*scale, h*scale)
+	if pp.out == nil || pp.out.Rect != rect {
+		pp.out = image.NewRGBA(rect)
+	}
+
+	cell := pixelSize * scale
+	for y := 0; y < rect.Dy(); y++ {
+		lastRow := y%cell == cell-1
+		for x := 0; x < rect.Dx(); x++ {
+			strength := 0.0
+			if lastRow {
+				strength = pp.Filters.Scanlines
+			}
+			if (lastRow || x%cell == cell-1) && pp.Filters.Grid > strength {
+				strength = pp.Filters.Grid
+			}
+			c := frame.RGBAAt(x/scale, y/scale)
+			pp.out.SetRGBA(x, y, darken(c, strength))
+		}
+	}
+	return pp.out
+}
+
+func darken(c color.RGBA, strength float64) color.RGBA {
+	if strength <= 0 {
+		return c
+	}
+	f := 1 - strength
+	return color.RGBA{
+		R: uint8(float64(c.R) * f),
+		G: uint8(float64(c.G) * f),
+		B: uint8(float64(c.B) * f),
+		A: c.A,
+	}
+}
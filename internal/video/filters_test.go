@@ -0,0 +1,96 @@
+package video
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newFrame(w, h int, c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestFiltersValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Nil(Filters{}.Validate())
+	assert.Nil(Filters{Persistence: 0.5, Scanlines: 1, Grid: 0.25}.Validate())
+	assert.NotNil(Filters{Persistence: 1}.Validate())
+	assert.NotNil(Filters{Scanlines: -0.1}.Validate())
+	assert.NotNil(Filters{Grid: 1.5}.Validate())
+}
+
+func TestProcessDisabled(t *testing.T) {
+	assert := assert.New(t)
+
+	var pp PostProcessor
+	frame := newFrame(4, 2, color.RGBA{255, 255, 255, 255})
+	assert.True(frame == pp.Process(frame, 1, 1))
+}
+
+func TestPersistence(t *testing.T) {
+	assert := assert.New(t)
+
+	pp := PostProcessor{Filters: Filters{Persistence: 0.5}}
+	white := color.RGBA{255, 255, 255, 255}
+	black := color.RGBA{0, 0, 0, 255}
+
+	// The first frame is shown as is
+	img := pp.Process(newFrame(2, 2, white), 1, 1)
+	assert.Equal(white, img.RGBAAt(1, 1))
+
+	// An erased pixel fades out once per emulated frame, redrawing the window without a tick shows the same image
+	img = pp.Process(newFrame(2, 2, black), 1, 1)
+	assert.Equal(color.RGBA{128, 128, 128, 255}, img.RGBAAt(1, 1))
+	for i := 0; i < 100; i++ {
+		img = pp.Process(newFrame(2, 2, black), 1, 0)
+	}
+	assert.Equal(color.RGBA{128, 128, 128, 255}, img.RGBAAt(1, 1))
+	img = pp.Process(newFrame(2, 2, black), 1, 1)
+	assert.Equal(color.RGBA{64, 64, 64, 255}, img.RGBAAt(1, 1))
+	img = pp.Process(newFrame(2, 2, black), 1, 2)
+	assert.Equal(color.RGBA{16, 16, 16, 255}, img.RGBAAt(1, 1))
+
+	// A resolution change starts over
+	img = pp.Process(newFrame(4, 2, white), 1, 1)
+	assert.Equal(white, img.RGBAAt(3, 1))
+
+	pp.Reset()
+	img = pp.Process(newFrame(4, 2, black), 1, 1)
+	assert.Equal(black, img.RGBAAt(3, 1))
+}
+
+func TestOverlay(t *testing.T) {
+	assert := assert.New(t)
+
+	white := color.RGBA{200, 200, 200, 255}
+	pp := PostProcessor{Filters: Filters{Scanlines: 0.5}}
+	img := pp.Process(newFrame(4, 2, white), 2, 1)
+	assert.Equal(image.Rect(0, 0, 8, 4), img.Rect)
+
+	// Low resolution pixels are doubled in the frame, so the scanline is below every second frame row
+	assert.Equal(white, img.RGBAAt(0, 1))
+	assert.Equal(white, img.RGBAAt(7, 2))
+	assert.Equal(color.RGBA{100, 100, 100, 255}, img.RGBAAt(0, 3))
+	assert.Equal(color.RGBA{100, 100, 100, 255}, img.RGBAAt(7, 3))
+
+	// Large pixels aren't enlarged
+	img = pp.Process(newFrame(8, 4, white), 4, 1)
+	assert.Equal(image.Rect(0, 0, 8, 4), img.Rect)
+	assert.Equal(color.RGBA{100, 100, 100, 255}, img.RGBAAt(5, 3))
+
+	pp.Filters = Filters{Grid: 1}
+	img = pp.Process(newFrame(4, 2, white), 1, 1)
+	assert.Equal(white, img.RGBAAt(0, 0))
+	assert.Equal(color.RGBA{0, 0, 0, 255}, img.RGBAAt(3, 0))
+	assert.Equal(color.RGBA{0, 0, 0, 255}, img.RGBAAt(0, 3))
+	assert.Equal(white, img.RGBAAt(4, 2))
+}
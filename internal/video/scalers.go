@@ -0,0 +1,312 @@
+package video
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// Scaler is a pixel-art upscaling algorithm, which is applied to the CHIP-8 pixels before the frame is drawn
+type Scaler int
+
+const (
+	// NoScaler keeps the pixels as they are, the window is drawn with nearest-neighbour scaling
+	NoScaler Scaler = iota
+	// Scale2x doubles the size and rounds the diagonal edges (also known as EPX or AdvMAME2x)
+	Scale2x
+	// Scale3x triples the size and rounds the diagonal edges (AdvMAME3x)
+	Scale3x
+	// Scale4x applies Scale2x twice
+	Scale4x
+	// Eagle doubles the size and fills the corners of a pixel with the color of three matching neighbours
+	Eagle
+	// HQ2x doubles the size like Scale2x, but compares the colors by similarity and blends the edges
+	HQ2x
+)
+
+// Scalers contains all scalers in the order they're switched through
+var Scalers = [...]Scaler{NoScaler, Scale2x, Scale3x, Scale4x, Eagle, HQ2x}
+
+var scalerNames = [...]string{"none", "scale2x", "scale3x", "scale4x", "eagle", "hq2x"}
+
+func (s Scaler) String() string {
+	if s < 0 || int(s) >= len(scalerNames) {
+		return "invalid"
+	}
+	return scalerNames[s]
+}
+
+// ParseScaler parses the name of a scaler
+func ParseScaler(s string) (Scaler, error) {
+	for _, scaler := range Scalers {
+		if scaler.String() == s {
+			return scaler, nil
+		}
+	}
+	return NoScaler, fmt.Errorf("unknown scaler %q", s)
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (s Scaler) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (s *Scaler) UnmarshalText(text []byte) error {
+	parsed, err := ParseScaler(string(text))
+	if err != nil {
+		return err
+	}
+	*s = parsed
+	return nil
+}
+
+// Factor returns the factor the CHIP-8 pixels are enlarged by
+func (s Scaler) Factor() int {
+	switch s {
+	case Scale2x, Eagle, HQ2x:
+		return 2
+	case Scale3x:
+		return 3
+	case Scale4x:
+		return 4
+	}
+	return 1
+}
+
+// pixels is a frame with one entry per CHIP-8 pixel, the neighbours outside are the nearest edge pixels
+type pixels struct {
+	w, h int
+	pix  []color.RGBA
+}
+
+func newPixels(w, h int) *pixels {
+	return &pixels{w: w, h: h, pix: make([]color.RGBA, w*h)}
+}
+
+func (p *pixels) at(x, y int) color.RGBA {
+	if x < 0 {
+		x = 0
+	} else if x >= p.w {
+		x = p.w - 1
+	}
+	if y < 0 {
+		y = 0
+	} else if y >= p.h {
+		y = p.h - 1
+	}
+	return p.pix[y*p.w+x]
+}
+
+func (p *pixels) set(x, y int, c color.RGBA) {
+	p.pix[y*p.w+x] = c
+}
+
+// Scale applies the scaler to the given frame, pixelSize is the number of frame pixels per CHIP-8 pixel.
+// The result has the size of the CHIP-8 resolution multiplied by the factor of the scaler,
+// dst is reused if it has this size.
+func (s Scaler) Scale(frame *image.RGBA, pixelSize int, dst *image.RGBA) *image.RGBA {
+	if pixelSize < 1 {
+		pixelSize = 1
+	}
+
+	// The scalers work on the CHIP-8 pixels, so the doubled low resolution pixels are sampled once
+	src := newPixels(frame.Rect.Dx()/pixelSize, frame.Rect.Dy()/pixelSize)
+	for y := 0; y < src.h; y++ {
+		for x := 0; x < src.w; x++ {
+			src.set(x, y, frame.RGBAAt(frame.Rect.Min.X+x*pixelSize, frame.Rect.Min.Y+y*pixelSize))
+		}
+	}
+
+	var out *pixels
+	switch s {
+	case Scale2x:
+		out = scale2x(src)
+	case Scale3x:
+		out = scale3x(src)
+	case Scale4x:
+		out = scale2x(scale2x(src))
+	case Eagle:
+		out = eagle(src)
+	case HQ2x:
+		out = hq2x(src)
+	default:
+		out = src
+	}
+
+	rect := image.Rect(0, 0, out.w, out.h)
+	if dst == nil || dst.Rect != rect {
+		dst = image.NewRGBA(rect)
+	}
+	for y := 0; y < out.h; y++ {
+		for x := 0; x < out.w; x++ {
+			dst.SetRGBA(x, y, out.pix[y*out.w+x])
+		}
+	}
+	return dst
+}
+
+func scale2x(src *pixels) *pixels {
+	out := newPixels(src.w*2, src.h*2)
+	for y := 0; y < src.h; y++ {
+		for x := 0; x < src.w; x++ {
+			p := src.at(x, y)
+			a, b, c, d := src.at(x, y-1), src.at(x+1, y), src.at(x-1, y), src.at(x, y+1)
+			e0, e1, e2, e3 := p, p, p, p
+			if c == a && c != d && a != b {
+				e0 = a
+			}
+			if a == b && a != c && b != d {
+				e1 = b
+			}
+			if d == c && d != b && c != a {
+				e2 = c
+			}
+			if b == d && b != a && d != c {
+				e3 = d
+			}
+			out.set(2*x, 2*y, e0)
+			out.set(2*x+1, 2*y, e1)
+			out.set(2*x, 2*y+1, e2)
+			out.set(2*x+1, 2*y+1, e3)
+		}
+	}
+	return out
+}
+
+func scale3x(src *pixels) *pixels {
+	out := newPixels(src.w*3, src.h*3)
+	for y := 0; y < src.h; y++ {
+		for x := 0; x < src.w; x++ {
+			// a b c
+			// d e f
+			// g h i
+			a, b, c := src.at(x-1, y-1), src.at(x, y-1), src.at(x+1, y-1)
+			d, e, f := src.at(x-1, y), src.at(x, y), src.at(x+1, y)
+			g, h, i := src.at(x-1, y+1), src.at(x, y+1), src.at(x+1, y+1)
+
+			var res [9]color.RGBA
+			for k := range res {
+				res[k] = e
+			}
+			if b != h && d != f {
+				if d == b {
+					res[0] = d
+				}
+				if (d == b && e != c) || (b == f && e != a) {
+					res[1] = b
+				}
+				if b == f {
+					res[2] = f
+				}
+				if (d == b && e != g) || (d == h && e != a) {
+					res[3] = d
+				}
+				if (b == f && e != i) || (h == f && e != c) {
+					res[5] = f
+				}
+				if d == h {
+					res[6] = d
+				}
+				if (d == h && e != i) || (h == f && e != g) {
+					res[7] = h
+				}
+				if h == f {
+					res[8] = f
+				}
+			}
+			for k, col := range res {
+				out.set(3*x+k%3, 3*y+k/3, col)
+			}
+		}
+	}
+	return out
+}
+
+func eagle(src *pixels) *pixels {
+	out := newPixels(src.w*2, src.h*2)
+	for y := 0; y < src.h; y++ {
+		for x := 0; x < src.w; x++ {
+			p := src.at(x, y)
+			tl, t, tr := src.at(x-1, y-1), src.at(x, y-1), src.at(x+1, y-1)
+			l, r := src.at(x-1, y), src.at(x+1, y)
+			bl, b, br := src.at(x-1, y+1), src.at(x, y+1), src.at(x+1, y+1)
+
+			e0, e1, e2, e3 := p, p, p, p
+			if tl == t && t == l {
+				e0 = tl
+			}
+			if t == tr && tr == r {
+				e1 = tr
+			}
+			if l == bl && bl == b {
+				e2 = bl
+			}
+			if r == br && br == b {
+				e3 = br
+			}
+			out.set(2*x, 2*y, e0)
+			out.set(2*x+1, 2*y, e1)
+			out.set(2*x, 2*y+1, e2)
+			out.set(2*x+1, 2*y+1, e3)
+		}
+	}
+	return out
+}
+
+// hq2x is a simplified variant of the hq2x filter, it detects the edges with the rules of Scale2x,
+// but compares the colors in YUV space with the thresholds of hqx and blends the corners instead of replacing them
+func hq2x(src *pixels) *pixels {
+	out := newPixels(src.w*2, src.h*2)
+	for y := 0; y < src.h; y++ {
+		for x := 0; x < src.w; x++ {
+			p := src.at(x, y)
+			a, b, c, d := src.at(x, y-1), src.at(x+1, y), src.at(x-1, y), src.at(x, y+1)
+			e0, e1, e2, e3 := p, p, p, p
+			if similar(c, a) && !similar(c, d) && !similar(a, b) {
+				e0 = blend(p, a, c)
+			}
+			if similar(a, b) && !similar(a, c) && !similar(b, d) {
+				e1 = blend(p, b, a)
+			}
+			if similar(d, c) && !similar(d, b) && !similar(c, a) {
+				e2 = blend(p, c, d)
+			}
+			if similar(b, d) && !similar(b, a) && !similar(d, c) {
+				e3 = blend(p, d, b)
+			}
+			out.set(2*x, 2*y, e0)
+			out.set(2*x+1, 2*y, e1)
+			out.set(2*x, 2*y+1, e2)
+			out.set(2*x+1, 2*y+1, e3)
+		}
+	}
+	return out
+}
+
+// similar compares two colors with the YUV thresholds of hqx
+func similar(c1, c2 color.RGBA) bool {
+	y1, u1, v1 := color.RGBToYCbCr(c1.R, c1.G, c1.B)
+	y2, u2, v2 := color.RGBToYCbCr(c2.R, c2.G, c2.B)
+	return absDiff(y1, y2) <= 48 && absDiff(u1, u2) <= 7 && absDiff(v1, v2) <= 6
+}
+
+func absDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+// blend mixes the colors with the weights 2:1:1
+func blend(c1, c2, c3 color.RGBA) color.RGBA {
+	mix := func(a, b, c uint8) uint8 {
+		return uint8((2*int(a) + int(b) + int(c) + 2) / 4)
+	}
+	return color.RGBA{
+		R: mix(c1.R, c2.R, c3.R),
+		G: mix(c1.G, c2.G, c3.G),
+		B: mix(c1.B, c2.B, c3.B),
+		A: mix(c1.A, c2.A, c3.A),
+	}
+}
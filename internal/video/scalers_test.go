@@ -0,0 +1,173 @@
+package video
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	off = color.RGBA{0, 0, 0, 255}
+	on  = color.RGBA{255, 255, 255, 255}
+)
+
+// frameFromText creates a frame from rows of '#' (on) and '.' (off), every character is a square of pixelSize pixels
+func frameFromText(pixelSize int, rows ...string) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, len(rows[0])*pixelSize, len(rows)*pixelSize))
+	for y := 0; y < img.Rect.Dy(); y++ {
+		for x := 0; x < img.Rect.Dx(); x++ {
+			c := off
+			if rows[y/pixelSize][x/pixelSize] == '#' {
+				c = on
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func frameToText(img *image.RGBA) string {
+	var sb strings.Builder
+	for y := 0; y < img.Rect.Dy(); y++ {
+		for x := 0; x < img.Rect.Dx(); x++ {
+			switch img.RGBAAt(x, y) {
+			case on:
+				sb.WriteByte('#')
+			case off:
+				sb.WriteByte('.')
+			default:
+				sb.WriteByte('+')
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// diagonal is a diagonal line from the top left to the bottom right
+var diagonal = []string{
+	".....",
+	".#...",
+	"..#..",
+	"...#.",
+	".....",
+}
+
+func TestParseScaler(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, s := range Scalers {
+		parsed, err := ParseScaler(s.String())
+		assert.Nil(err)
+		assert.Equal(s, parsed)
+	}
+	_, err := ParseScaler("xbrz")
+	assert.NotNil(err)
+	assert.Equal(1, NoScaler.Factor())
+	assert.Equal(3, Scale3x.Factor())
+	assert.Equal(4, Scale4x.Factor())
+}
+
+func TestScale2x(t *testing.T) {
+	assert := assert.New(t)
+
+	// The doubled low resolution pixels are sampled once
+	img := Scale2x.Scale(frameFromText(2, diagonal...), 2, nil)
+	assert.Equal(""+
+		"..........\n"+
+		"..........\n"+
+		"..##......\n"+
+		"..###.....\n"+
+		"...###....\n"+
+		"....###...\n"+
+		".....###..\n"+
+		"......##..\n"+
+		"..........\n"+
+		"..........\n", frameToText(img))
+
+	// A single pixel stays square
+	img = Scale2x.Scale(frameFromText(1, "...", ".#.", "..."), 1, img)
+	assert.Equal("......\n......\n..##..\n..##..\n......\n......\n", frameToText(img))
+}
+
+func TestScale3x(t *testing.T) {
+	assert := assert.New(t)
+
+	img := Scale3x.Scale(frameFromText(1, diagonal...), 1, nil)
+	assert.Equal(""+
+		"...............\n"+
+		"...............\n"+
+		"...............\n"+
+		"...###.........\n"+
+		"...###.........\n"+
+		"...####........\n"+
+		".....####......\n"+
+		"......###......\n"+
+		"......####.....\n"+
+		"........####...\n"+
+		".........###...\n"+
+		".........###...\n"+
+		"...............\n"+
+		"...............\n"+
+		"...............\n", frameToText(img))
+}
+
+func TestScale4x(t *testing.T) {
+	assert := assert.New(t)
+
+	img := Scale4x.Scale(frameFromText(1, diagonal...), 1, nil)
+	assert.Equal(image.Rect(0, 0, 20, 20), img.Rect)
+	assert.Equal(on, img.RGBAAt(8, 7))
+	assert.Equal(off, img.RGBAAt(4, 8))
+}
+
+func TestEagle(t *testing.T) {
+	assert := assert.New(t)
+
+	// The corners are filled where three neighbours match
+	img := Eagle.Scale(frameFromText(1, ".....", ".##..", ".#...", "....."), 1, nil)
+	assert.Equal(""+
+		"..........\n"+
+		"..........\n"+
+		"...##.....\n"+
+		"..###.....\n"+
+		"..###.....\n"+
+		"..........\n"+
+		"..........\n"+
+		"..........\n", frameToText(img))
+}
+
+func TestHQ2x(t *testing.T) {
+	assert := assert.New(t)
+
+	// The edges are blended instead of filled
+	img := HQ2x.Scale(frameFromText(1, diagonal...), 1, nil)
+	assert.Equal(""+
+		"..........\n"+
+		"..........\n"+
+		"..##......\n"+
+		"..##+.....\n"+
+		"...+##....\n"+
+		"....##+...\n"+
+		".....+##..\n"+
+		"......##..\n"+
+		"..........\n"+
+		"..........\n", frameToText(img))
+	assert.Equal(color.RGBA{128, 128, 128, 255}, img.RGBAAt(4, 3))
+}
+
+func TestProcessScaler(t *testing.T) {
+	assert := assert.New(t)
+
+	pp := PostProcessor{Scaler: Scale4x, Filters: Filters{Grid: 1}}
+	img := pp.Process(frameFromText(2, diagonal...), 2, 1)
+
+	// The scaled pixels are large enough for the grid
+	assert.Equal(image.Rect(0, 0, 20, 20), img.Rect)
+	assert.Equal(on, img.RGBAAt(5, 5))
+	assert.Equal(off, img.RGBAAt(7, 5))
+	assert.Equal(off, img.RGBAAt(5, 7))
+}
@@ -0,0 +1,83 @@
+package video
+
+import (
+	"fmt"
+	"math"
+)
+
+// ScaleMode determines how the framebuffer is fit into the window
+type ScaleMode int
+
+const (
+	// Stretch fills the whole window, the pixels may be distorted
+	Stretch ScaleMode = iota
+	// Aspect keeps the aspect ratio and fills the remaining space with the border color
+	Aspect
+	// Integer scales by the largest integer factor which fits, or like Aspect if the window is too small
+	Integer
+)
+
+// ScaleModes contains all scale modes in the order they're switched through
+var ScaleModes = [...]ScaleMode{Stretch, Aspect, Integer}
+
+var scaleModeNames = [...]string{"stretch", "aspect", "integer"}
+
+func (m ScaleMode) String() string {
+	if m < 0 || int(m) >= len(scaleModeNames) {
+		return "invalid"
+	}
+	return scaleModeNames[m]
+}
+
+// ParseScaleMode parses the name of a scale mode
+func ParseScaleMode(s string) (ScaleMode, error) {
+	for _, m := range ScaleModes {
+		if m.String() == s {
+			return m, nil
+		}
+	}
+	return Stretch, fmt.Errorf("unknown scale mode %q", s)
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (m ScaleMode) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (m *ScaleMode) UnmarshalText(text []byte) error {
+	parsed, err := ParseScaleMode(string(text))
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Rect is a rectangle with the origin in the bottom left corner
+type Rect struct {
+	X, Y, W, H float64
+}
+
+// Fit returns the rectangle of the window with the given size which the framebuffer is drawn to.
+// The rectangle is centered and its position and size are whole pixels.
+func Fit(mode ScaleMode, frameWidth, frameHeight int, windowWidth, windowHeight float64) Rect {
+	if mode == Stretch || frameWidth <= 0 || frameHeight <= 0 {
+		return Rect{0, 0, windowWidth, windowHeight}
+	}
+
+	fw, fh := float64(frameWidth), float64(frameHeight)
+	scale := math.Min(windowWidth/fw, windowHeight/fh)
+	if mode == Integer && scale >= 1 {
+		scale = math.Floor(scale)
+	}
+
+	w := math.Floor(fw * scale)
+	h := math.Floor(fh * scale)
+	return Rect{
+		X: math.Floor((windowWidth - w) / 2),
+		Y: math.Floor((windowHeight - h) / 2),
+		W: w,
+		H: h,
+	}
+}
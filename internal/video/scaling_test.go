@@ -0,0 +1,37 @@
+package video
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseScaleMode(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, m := range ScaleModes {
+		parsed, err := ParseScaleMode(m.String())
+		assert.Nil(err)
+		assert.Equal(m, parsed)
+	}
+	_, err := ParseScaleMode("zoom")
+	assert.NotNil(err)
+	assert.Equal("invalid", ScaleMode(5).String())
+}
+
+func TestFit(t *testing.T) {
+	assert := assert.New(t)
+
+	// Stretch fills the window
+	assert.Equal(Rect{0, 0, 1920, 1080}, Fit(Stretch, 128, 64, 1920, 1080))
+
+	// Aspect letterboxes on 16:9 and pillarboxes for the square HiRes mode
+	assert.Equal(Rect{0, 60, 1920, 960}, Fit(Aspect, 128, 64, 1920, 1080))
+	assert.Equal(Rect{420, 0, 1080, 1080}, Fit(Aspect, 64, 64, 1920, 1080))
+
+	// Integer uses whole multiples of the framebuffer
+	assert.Equal(Rect{0, 60, 1920, 960}, Fit(Integer, 128, 64, 1920, 1080))
+	assert.Equal(Rect{43, 64, 1280, 640}, Fit(Integer, 128, 64, 1366, 768))
+	assert.Equal(Rect{0, 0, 640, 320}, Fit(Integer, 128, 64, 640, 320))
+	assert.Equal(Rect{0, 5, 100, 50}, Fit(Integer, 128, 64, 100, 60))
+}
@@ -0,0 +1,75 @@
+package video
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Resize returns the frame in the CHIP-8 resolution with every CHIP-8 pixel enlarged to a square of the given size,
+// pixelSize is the number of frame pixels per CHIP-8 pixel
+func Resize(frame *image.RGBA, pixelSize, scale int) *image.RGBA {
+	if pixelSize < 1 {
+		pixelSize = 1
+	}
+	if scale < 1 {
+		scale = 1
+	}
+
+	w, h := frame.Rect.Dx()/pixelSize, frame.Rect.Dy()/pixelSize
+	img := image.NewRGBA(image.Rect(0, 0, w*scale, h*scale))
+	for y := 0; y < h*scale; y++ {
+		for x := 0; x < w*scale; x++ {
+			img.SetRGBA(x, y, frame.RGBAAt(frame.Rect.Min.X+x/scale*pixelSize, frame.Rect.Min.Y+y/scale*pixelSize))
+		}
+	}
+	return img
+}
+
+// ScreenshotName returns the file name of a screenshot of the given ROM taken at the given time
+func ScreenshotName(title string, t time.Time) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, strings.TrimSpace(title))
+	if name == "" {
+		name = "screenshot"
+	}
+	return fmt.Sprintf("%v-%v.png", name, t.Format("20060102-150405"))
+}
+
+// UniquePath returns the given path if no such file exists,
+// otherwise a counter is appended to the file name, so files saved within the same second don't overwrite each other
+func UniquePath(path string) string {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	for i := 2; ; i++ {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return path
+		}
+		path = fmt.Sprintf("%v-%v%v", base, i, ext)
+	}
+}
+
+// SavePNG writes the image to the given PNG file, the directory is created if needed
+func SavePNG(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(file, img); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,73 @@
+package video
+
+import (
+	"image"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResize(t *testing.T) {
+	assert := assert.New(t)
+
+	// The doubled low resolution pixels are reduced to the native resolution
+	frame := frameFromText(2, "#.", ".#")
+	img := Resize(frame, 2, 1)
+	assert.Equal(image.Rect(0, 0, 2, 2), img.Rect)
+	assert.Equal("#.\n.#\n", frameToText(img))
+
+	img = Resize(frame, 2, 3)
+	assert.Equal(image.Rect(0, 0, 6, 6), img.Rect)
+	assert.Equal(on, img.RGBAAt(2, 2))
+	assert.Equal(off, img.RGBAAt(3, 2))
+	assert.Equal(on, img.RGBAAt(5, 5))
+}
+
+func TestScreenshotName(t *testing.T) {
+	assert := assert.New(t)
+
+	at := time.Date(2020, 5, 17, 13, 4, 5, 0, time.UTC)
+	assert.Equal("Space_Invaders-20200517-130405.png", ScreenshotName("Space Invaders", at))
+	assert.Equal("a_b_c-20200517-130405.png", ScreenshotName("a/b\\c", at))
+	assert.Equal("screenshot-20200517-130405.png", ScreenshotName("", at))
+}
+
+func TestSavePNG(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "pich8-screenshot")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "screenshots", "test.png")
+	assert.Nil(SavePNG(path, frameFromText(1, "#.", "##")))
+
+	file, err := os.Open(path)
+	assert.Nil(err)
+	defer file.Close()
+	img, err := png.Decode(file)
+	assert.Nil(err)
+	assert.Equal(image.Rect(0, 0, 2, 2), img.Bounds())
+	r, _, _, _ := img.At(0, 1).RGBA()
+	assert.Equal(uint32(0xffff), r)
+}
+
+func TestUniquePath(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "pich8-screenshot")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "test.png")
+	assert.Equal(path, UniquePath(path))
+	assert.Nil(ioutil.WriteFile(path, nil, 0644))
+	assert.Equal(filepath.Join(dir, "test-2.png"), UniquePath(path))
+	assert.Nil(ioutil.WriteFile(filepath.Join(dir, "test-2.png"), nil, 0644))
+	assert.Equal(filepath.Join(dir, "test-3.png"), UniquePath(path))
+}
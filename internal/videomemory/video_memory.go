@@ -1,5 +1,7 @@
 package videomemory
 
+import "errors"
+
 type VideoMode byte
 type Plane byte
 
@@ -233,3 +235,43 @@ func (vmem *VideoMemory) ScrollRight() {
 		}
 	}
 }
+
+// stateSize is the size of the encoded VideoMemory, the mode and plane followed by the packed bits of both planes
+const stateSize = 2 + 2*widthExtended*heightExtended/8
+
+// MarshalBinary encodes the mode, the selected plane and the content of both planes
+func (vmem *VideoMemory) MarshalBinary() ([]byte, error) {
+	data := make([]byte, stateSize)
+	data[0] = byte(vmem.VideoMode)
+	data[1] = byte(vmem.Plane)
+	bits := data[2:]
+	for i := range vmem.vmemPlane1 {
+		if vmem.vmemPlane1[i] {
+			bits[i/8] |= 1 << (i % 8)
+		}
+		if vmem.vmemPlane2[i] {
+			bits[len(bits)/2+i/8] |= 1 << (i % 8)
+		}
+	}
+	return data, nil
+}
+
+// UnmarshalBinary restores the state encoded by MarshalBinary
+func (vmem *VideoMemory) UnmarshalBinary(data []byte) error {
+	if len(data) != stateSize {
+		return errors.New("invalid video memory state")
+	}
+	mode, plane := VideoMode(data[0]), Plane(data[1])
+	if mode < DefaultVideoMode || mode > ExtendedVideoMode || plane > BothPlanes {
+		return errors.New("invalid video memory state")
+	}
+
+	vmem.VideoMode = mode
+	vmem.Plane = plane
+	bits := data[2:]
+	for i := range vmem.vmemPlane1 {
+		vmem.vmemPlane1[i] = bits[i/8]&(1<<(i%8)) != 0
+		vmem.vmemPlane2[i] = bits[len(bits)/2+i/8]&(1<<(i%8)) != 0
+	}
+	return nil
+}
@@ -326,3 +326,23 @@ func TestPlanes(t *testing.T) {
 		}
 	}
 }
+
+func TestMarshalBinary(t *testing.T) {
+	assert := assert.New(t)
+
+	vmem := NewVideoMemory()
+	vmem.VideoMode = ExtendedVideoMode
+	vmem.Plane = BothPlanes
+	vmem.Set(FirstPlane, 3, 5, true)
+	vmem.Set(SecondPlane, 127, 63, true)
+	data, err := vmem.MarshalBinary()
+	assert.Nil(err)
+
+	restored := NewVideoMemory()
+	assert.Nil(restored.UnmarshalBinary(data))
+	assert.Equal(*vmem, *restored)
+
+	assert.NotNil(restored.UnmarshalBinary(data[1:]))
+	data[0] = 9
+	assert.NotNil(restored.UnmarshalBinary(data))
+}
@@ -1,17 +1,39 @@
 package main
 
 import (
-	"github.com/faiface/pixel/pixelgl"
-	"github.com/philw07/pich8-go/internal/emulator"
+	"flag"
+	"fmt"
+	"os"
 )
 
+// commands contains the subcommands which run without window
+var commands = map[string]func(args []string) error{
+	"bench":  runBench,
+	"replay": runReplay,
+	"detect": runDetect,
+	"help":   runHelp,
+}
+
 func main() {
+	run := runGui
+	args := os.Args[1:]
+	if len(args) > 0 {
+		if command, ok := commands[args[0]]; ok {
+			run = command
+			args = args[1:]
+		}
+	}
 
-	pixelgl.Run(func() {
-		emu, err := emulator.NewEmulator()
-		if err != nil {
-			panic(err)
+	if err := run(args); err != nil {
+		if err != flag.ErrHelp {
+			fmt.Fprintln(os.Stderr, err)
 		}
-		emu.Run()
-	})
+		os.Exit(1)
+	}
+}
+
+// runHelp prints the usage of the emulator and its subcommands
+func runHelp(args []string) error {
+	newGuiOptions().flags.Usage()
+	return nil
 }
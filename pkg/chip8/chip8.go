@@ -0,0 +1,339 @@
+// Package chip8 is the embeddable CHIP-8, SUPER-CHIP and XO-CHIP core of pich8-go.
+//
+// A Machine emulates the CPU, the timers and the video memory without any window, audio output or wall clock,
+// it's advanced by single instructions or whole frames of 1/60s. The caller passes in the key states,
+// reads the framebuffer and the audio state after every frame and can save and restore snapshots at any time.
+//
+// The package follows semantic versioning, Version is increased with every release of the API.
+// Within a major version, existing functions and types keep working and snapshots stay loadable.
+package chip8
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/philw07/pich8-go/internal/cpu"
+)
+
+// Version is the semantic version of the API
+const Version = "1.0.0"
+
+const (
+	// FrameRate is the number of frames per second, the timers count down once per frame
+	FrameRate = 60
+	// DefaultSpeed is the default number of instructions per second
+	DefaultSpeed = 720
+)
+
+// Quirks are the behaviors which differ between the CHIP-8 platforms
+type Quirks struct {
+	// LoadStore makes FX55 and FX65 leave I unchanged
+	LoadStore bool
+	// Shift makes 8XY6 and 8XYE shift VX instead of VY
+	Shift bool
+	// Jump makes BNNN jump to XNN + VX instead of NNN + V0
+	Jump bool
+	// VfOrder makes 8XYN write VF after the result register
+	VfOrder bool
+	// Draw makes DXY0 draw 16x16 sprites in low resolution
+	Draw bool
+}
+
+// Profiles returns the names of the platform profiles in a fixed order
+func Profiles() []string {
+	return append([]string{}, cpu.ProfileNames[:]...)
+}
+
+// ProfileQuirks returns the quirks of the platform profile with the given name
+func ProfileQuirks(name string) (Quirks, error) {
+	q, err := cpu.ProfileQuirks(name)
+	return Quirks(q), err
+}
+
+// Option configures a Machine created by New
+type Option func(m *Machine) error
+
+// WithProfile sets the quirks of the platform profile with the given name, see Profiles
+func WithProfile(name string) Option {
+	return func(m *Machine) error {
+		q, err := ProfileQuirks(name)
+		m.quirks = q
+		return err
+	}
+}
+
+// WithQuirks sets the given quirks
+func WithQuirks(quirks Quirks) Option {
+	return func(m *Machine) error {
+		m.quirks = quirks
+		return nil
+	}
+}
+
+// WithSpeed sets the number of instructions per second, which are executed in equal parts per frame
+func WithSpeed(instructionsPerSecond int) Option {
+	return func(m *Machine) error {
+		if instructionsPerSecond < FrameRate {
+			return fmt.Errorf("invalid speed %v, at least %v instructions per second are required", instructionsPerSecond, FrameRate)
+		}
+		m.instructionsPerFrame = instructionsPerSecond / FrameRate
+		return nil
+	}
+}
+
+// WithSeed seeds the random number generator with the given value on every reset instead of the current time,
+// which makes the emulation deterministic
+func WithSeed(seed int64) Option {
+	return func(m *Machine) error {
+		m.seed = seed
+		m.seeded = true
+		return nil
+	}
+}
+
+// Machine is an emulated CHIP-8 machine, it isn't safe for concurrent use
+type Machine struct {
+	cpu                  *cpu.CPU
+	rom                  []byte
+	quirks               Quirks
+	instructionsPerFrame int
+	seed                 int64
+	seeded               bool
+	frame                uint64
+}
+
+// New creates a machine with the given options, by default it runs the pich8 profile at DefaultSpeed.
+// The memory is empty until a ROM is loaded.
+func New(options ...Option) (*Machine, error) {
+	m := Machine{
+		quirks:               Quirks(cpu.Profiles[cpu.DefaultProfile]),
+		instructionsPerFrame: DefaultSpeed / FrameRate,
+	}
+	for _, option := range options {
+		if err := option(&m); err != nil {
+			return nil, err
+		}
+	}
+	if err := m.Reset(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// LoadROM loads the given ROM and resets the machine
+func (m *Machine) LoadROM(rom []byte) error {
+	if len(rom) > 0x10000-0x200 {
+		return errors.New("ROM too large")
+	}
+	m.rom = append([]byte{}, rom...)
+	return m.Reset()
+}
+
+// Reset restarts the loaded ROM, the flag registers are cleared
+func (m *Machine) Reset() error {
+	c := cpu.NewCPU()
+	if err := c.LoadRom(m.rom); err != nil {
+		return err
+	}
+	c.SetQuirks(cpu.Quirks(m.quirks))
+	if m.seeded {
+		c.Seed(m.seed)
+	}
+	m.cpu = c
+	m.frame = 0
+	return nil
+}
+
+// Quirks returns the active quirks
+func (m *Machine) Quirks() Quirks {
+	return Quirks(m.cpu.Quirks())
+}
+
+// SetQuirks changes the quirks, they stay active after a reset
+func (m *Machine) SetQuirks(quirks Quirks) {
+	m.quirks = quirks
+	m.cpu.SetQuirks(cpu.Quirks(quirks))
+}
+
+// InstructionsPerFrame returns the number of instructions executed by RunFrame
+func (m *Machine) InstructionsPerFrame() int {
+	return m.instructionsPerFrame
+}
+
+// SetKey presses or releases the given key between 0x0 and 0xF.
+// A short press is latched until the ROM tests the key, so it isn't lost if it's released before the next instruction.
+func (m *Machine) SetKey(key byte, pressed bool) {
+	m.cpu.SetKey(key, pressed)
+}
+
+// SetKeys sets the states of all keys, the index is the key
+func (m *Machine) SetKeys(keys [16]bool) {
+	current := m.cpu.PressedKeys()
+	for key, pressed := range keys {
+		if pressed != current[key] {
+			m.cpu.SetKey(byte(key), pressed)
+		}
+	}
+}
+
+// Keys returns the states of all keys
+func (m *Machine) Keys() [16]bool {
+	return m.cpu.PressedKeys()
+}
+
+// Step executes a single instruction, the timers aren't updated.
+// While the ROM waits for a key press with FX0A, no instruction is executed.
+func (m *Machine) Step() error {
+	return m.cpu.Step()
+}
+
+// TickTimers counts the delay and sound timers down, it's called once per frame by RunFrame
+func (m *Machine) TickTimers() {
+	m.cpu.UpdateTimers()
+}
+
+// RunFrame executes the instructions of one frame followed by a timer tick.
+// Invalid instructions are skipped like by the emulator, the memory accessed through I wraps around at its end.
+func (m *Machine) RunFrame() error {
+	for i := 0; i < m.instructionsPerFrame; i++ {
+		if err := m.cpu.Step(); err != nil {
+			return err
+		}
+	}
+	m.cpu.UpdateTimers()
+	m.frame++
+	return nil
+}
+
+// Frame returns the number of frames run since the last reset
+func (m *Machine) Frame() uint64 {
+	return m.frame
+}
+
+// WaitingForKey returns whether the ROM waits for a key press with FX0A
+func (m *Machine) WaitingForKey() bool {
+	return m.cpu.WaitingForKey()
+}
+
+// Registers are the registers of the CPU
+type Registers struct {
+	PC uint16
+	I  uint16
+	V  [16]byte
+	// DT and ST are the delay and sound timers
+	DT byte
+	ST byte
+	// RPL are the SUPER-CHIP flag registers, which ROMs use to save data like high scores
+	RPL [16]byte
+}
+
+// Registers returns the current values of the registers
+func (m *Machine) Registers() Registers {
+	return Registers{PC: m.cpu.PC, I: m.cpu.I, V: m.cpu.V, DT: m.cpu.DT, ST: m.cpu.ST, RPL: m.cpu.RPL}
+}
+
+// SetFlags sets the flag registers, e.g. to restore saved high scores after loading a ROM
+func (m *Machine) SetFlags(flags [16]byte) {
+	m.cpu.RPL = flags
+}
+
+// Audio is the sound state of the machine
+type Audio struct {
+	// SoundTimer is the value of the sound timer, the sound plays while it's above zero
+	SoundTimer byte
+	// Pattern holds the 128 1-bit samples of the XO-CHIP audio pattern, the most significant bit first.
+	// It's played in a loop at 4000 samples per second if HasPattern is set, otherwise a beep is played.
+	Pattern    [16]byte
+	HasPattern bool
+}
+
+// Playing returns whether the sound plays
+func (a Audio) Playing() bool {
+	return a.SoundTimer > 0
+}
+
+// Audio returns the current sound state
+func (m *Machine) Audio() Audio {
+	audio := Audio{SoundTimer: m.cpu.ST}
+	if buffer := m.cpu.AudioBuffer(); buffer != nil {
+		audio.Pattern = *buffer
+		audio.HasPattern = true
+	}
+	return audio
+}
+
+// snapshotVersion is increased whenever the layout of the snapshots changes
+const snapshotVersion = 1
+
+var snapshotMagic = [4]byte{'P', '8', 'S', 'N'}
+
+// snapshotHeader precedes the ROM and the CPU state in a snapshot
+type snapshotHeader struct {
+	Magic                [4]byte
+	Version              uint16
+	Quirks               Quirks
+	InstructionsPerFrame uint32
+	Seed                 int64
+	Seeded               bool
+	Frame                uint64
+	RomSize              uint32
+}
+
+// Snapshot returns the complete state of the machine, including the loaded ROM and the random number generator
+func (m *Machine) Snapshot() ([]byte, error) {
+	header := snapshotHeader{
+		Magic:                snapshotMagic,
+		Version:              snapshotVersion,
+		Quirks:               m.quirks,
+		InstructionsPerFrame: uint32(m.instructionsPerFrame),
+		Seed:                 m.seed,
+		Seeded:               m.seeded,
+		Frame:                m.frame,
+		RomSize:              uint32(len(m.rom)),
+	}
+	state, err := m.cpu.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := binary.Write(&buf, binary.LittleEndian, &header); err != nil {
+		return nil, err
+	}
+	buf.Write(m.rom)
+	buf.Write(state)
+	return buf.Bytes(), nil
+}
+
+// Restore restores the state of a snapshot, the machine continues exactly like the one the snapshot was taken of
+func (m *Machine) Restore(snapshot []byte) error {
+	var header snapshotHeader
+	r := bytes.NewReader(snapshot)
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil || header.Magic != snapshotMagic {
+		return errors.New("invalid snapshot")
+	}
+	if header.Version != snapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %v", header.Version)
+	}
+	if int64(header.RomSize) > int64(r.Len()) || header.InstructionsPerFrame == 0 {
+		return errors.New("invalid snapshot")
+	}
+	rom := make([]byte, header.RomSize)
+	r.Read(rom)
+
+	c := cpu.NewCPU()
+	if err := c.UnmarshalBinary(snapshot[len(snapshot)-r.Len():]); err != nil {
+		return fmt.Errorf("invalid snapshot: %v", err)
+	}
+
+	m.cpu = c
+	m.rom = rom
+	m.quirks = header.Quirks
+	m.instructionsPerFrame = int(header.InstructionsPerFrame)
+	m.seed = header.Seed
+	m.seeded = header.Seeded
+	m.frame = header.Frame
+	return nil
+}
@@ -0,0 +1,140 @@
+package chip8
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptions(t *testing.T) {
+	assert := assert.New(t)
+
+	m, err := New()
+	assert.Nil(err)
+	assert.Equal(DefaultSpeed/FrameRate, m.InstructionsPerFrame())
+	quirks, _ := ProfileQuirks("pich8")
+	assert.Equal(quirks, m.Quirks())
+
+	m, err = New(WithProfile("xochip"), WithSpeed(60000))
+	assert.Nil(err)
+	assert.Equal(1000, m.InstructionsPerFrame())
+	quirks, _ = ProfileQuirks("xochip")
+	assert.Equal(quirks, m.Quirks())
+
+	_, err = New(WithProfile("unknown"))
+	assert.NotNil(err)
+	_, err = New(WithSpeed(10))
+	assert.NotNil(err)
+	assert.Contains(Profiles(), "schip")
+}
+
+func TestQuirksSurviveReset(t *testing.T) {
+	assert := assert.New(t)
+
+	m, _ := New()
+	m.SetQuirks(Quirks{Shift: true})
+	assert.Nil(m.LoadROM([]byte{0x12, 0x00}))
+	assert.Equal(Quirks{Shift: true}, m.Quirks())
+
+	assert.NotNil(m.LoadROM(make([]byte, 0x10000)))
+}
+
+func TestFramebuffer(t *testing.T) {
+	assert := assert.New(t)
+
+	m, _ := New(WithProfile("xochip"))
+	// High resolution, both planes, draw the font digits 0 and 1 to the planes at (2, 1)
+	rom := []byte{0x00, 0xFF, 0xF3, 0x01, 0x60, 0x02, 0x61, 0x01, 0xA0, 0x00, 0xD0, 0x15, 0x12, 0x0C}
+	assert.Nil(m.LoadROM(rom))
+	assert.Nil(m.RunFrame())
+
+	fb := m.Framebuffer()
+	assert.Equal(128, fb.Width)
+	assert.Equal(64, fb.Height)
+	assert.Len(fb.Pixels, 128*64)
+	assert.EqualValues(1, fb.At(2, 1))
+	assert.EqualValues(3, fb.At(4, 1))
+	assert.EqualValues(2, fb.At(3, 2))
+	assert.EqualValues(1, fb.At(5, 2))
+	assert.EqualValues(0, fb.At(0, 0))
+}
+
+func TestAudioPattern(t *testing.T) {
+	assert := assert.New(t)
+
+	m, _ := New(WithProfile("xochip"))
+	// Load the pattern at 0x208 and start the sound
+	rom := []byte{0xA2, 0x0A, 0xF0, 0x02, 0x60, 0x02, 0xF0, 0x18, 0x12, 0x08, 0xAA, 0x55}
+	assert.Nil(m.LoadROM(rom))
+	assert.Nil(m.RunFrame())
+
+	audio := m.Audio()
+	assert.True(audio.Playing())
+	assert.True(audio.HasPattern)
+	assert.EqualValues(0xAA, audio.Pattern[0])
+	assert.EqualValues(0x55, audio.Pattern[1])
+}
+
+func TestMemoryWrap(t *testing.T) {
+	assert := assert.New(t)
+
+	// The sprite at I = 0xFFF8 continues with the font at the start of the memory
+	m, _ := New()
+	assert.Nil(m.LoadROM([]byte{0xF0, 0x00, 0xFF, 0xF8, 0xD0, 0x1F, 0x12, 0x06}))
+	assert.Nil(m.RunFrame())
+	fb := m.Framebuffer()
+	assert.EqualValues(0, fb.At(0, 7))
+	assert.EqualValues(1, fb.At(0, 8))
+	assert.EqualValues(1, fb.At(3, 8))
+	assert.EqualValues(0, fb.At(4, 8))
+
+	// BCD, audio pattern and register load at I = 0xFFFE
+	m, _ = New(WithProfile("xochip"))
+	rom := []byte{0xF0, 0x00, 0xFF, 0xFE, 0x6A, 0x7B, 0xFA, 0x33, 0xF0, 0x02, 0xF2, 0x65, 0x12, 0x0C}
+	assert.Nil(m.LoadROM(rom))
+	assert.Nil(m.RunFrame())
+	regs := m.Registers()
+	assert.Equal([]byte{1, 2, 3}, regs.V[:3])
+	assert.EqualValues(1, regs.I)
+	audio := m.Audio()
+	assert.Equal([]byte{1, 2, 3, 0x90}, audio.Pattern[:4])
+}
+
+func TestSnapshot(t *testing.T) {
+	assert := assert.New(t)
+
+	m, _ := New(WithSeed(7), WithSpeed(1200))
+	rom := []byte{0xC0, 0x3F, 0xC1, 0x1F, 0xA0, 0x00, 0xD0, 0x15, 0x12, 0x00}
+	assert.Nil(m.LoadROM(rom))
+	m.SetKey(3, true)
+	m.SetFlags([16]byte{1, 2, 3})
+	for i := 0; i < 5; i++ {
+		m.RunFrame()
+	}
+	snapshot, err := m.Snapshot()
+	assert.Nil(err)
+
+	other, _ := New()
+	assert.Nil(other.Restore(snapshot))
+	assert.Equal(m.Registers(), other.Registers())
+	assert.Equal(m.Keys(), other.Keys())
+	assert.Equal(m.Frame(), other.Frame())
+	assert.Equal(m.InstructionsPerFrame(), other.InstructionsPerFrame())
+	for i := 0; i < 30; i++ {
+		m.RunFrame()
+		other.RunFrame()
+	}
+	assert.Equal(m.Framebuffer(), other.Framebuffer())
+	assert.Equal(m.Registers(), other.Registers())
+
+	// The ROM is restored along with the state
+	assert.Nil(other.Reset())
+	assert.Nil(m.Reset())
+	m.RunFrame()
+	other.RunFrame()
+	assert.Equal(m.Framebuffer(), other.Framebuffer())
+
+	assert.NotNil(other.Restore(snapshot[:20]))
+	assert.NotNil(other.Restore(snapshot[:len(snapshot)-1]))
+	assert.NotNil(other.Restore([]byte("not a snapshot at all, but long enough for a header")))
+}
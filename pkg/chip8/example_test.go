@@ -0,0 +1,114 @@
+package chip8_test
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	"github.com/philw07/pich8-go/pkg/chip8"
+)
+
+func Example() {
+	m, err := chip8.New(chip8.WithProfile("chip8"), chip8.WithSpeed(600))
+	if err != nil {
+		panic(err)
+	}
+
+	// Draws the digit 5 of the font to the top left corner
+	rom := []byte{0x60, 0x05, 0xF0, 0x29, 0x61, 0x00, 0xD1, 0x15, 0x12, 0x08}
+	if err := m.LoadROM(rom); err != nil {
+		panic(err)
+	}
+	if err := m.RunFrame(); err != nil {
+		panic(err)
+	}
+
+	fb := m.Framebuffer()
+	fmt.Printf("%vx%v\n", fb.Width, fb.Height)
+	for y := 0; y < 5; y++ {
+		var sb strings.Builder
+		for x := 0; x < 4; x++ {
+			if fb.At(x, y) != 0 {
+				sb.WriteByte('#')
+			} else {
+				sb.WriteByte('.')
+			}
+		}
+		fmt.Println(sb.String())
+	}
+	// Output:
+	// 64x32
+	// ####
+	// #...
+	// ####
+	// ...#
+	// ####
+}
+
+func ExampleMachine_SetKey() {
+	m, _ := chip8.New()
+
+	// Waits for a key and stores it in V0
+	m.LoadROM([]byte{0xF0, 0x0A, 0x12, 0x02})
+	m.Step()
+	fmt.Println(m.WaitingForKey())
+
+	// The key is stored when it's released
+	m.SetKey(0xA, true)
+	m.SetKey(0xA, false)
+	fmt.Println(m.WaitingForKey(), m.Registers().V[0])
+	// Output:
+	// true
+	// false 10
+}
+
+func ExampleMachine_Audio() {
+	m, _ := chip8.New()
+
+	// Sets the sound timer to 5
+	m.LoadROM([]byte{0x60, 0x05, 0xF0, 0x18, 0x12, 0x04})
+	m.RunFrame()
+	audio := m.Audio()
+	fmt.Println(audio.Playing(), audio.SoundTimer, audio.HasPattern)
+	// Output: true 4 false
+}
+
+func ExampleMachine_Snapshot() {
+	m, _ := chip8.New(chip8.WithSeed(42))
+
+	// Draws random sprites to random positions
+	m.LoadROM([]byte{0xC0, 0x3F, 0xC1, 0x1F, 0xC2, 0xFF, 0xA3, 0x00, 0xF2, 0x55, 0xD0, 0x11, 0x12, 0x00})
+	m.RunFrame()
+	snapshot, err := m.Snapshot()
+	if err != nil {
+		panic(err)
+	}
+
+	for i := 0; i < 10; i++ {
+		m.RunFrame()
+	}
+	before := m.Framebuffer()
+
+	// The restored machine draws the same frames again
+	if err := m.Restore(snapshot); err != nil {
+		panic(err)
+	}
+	fmt.Println(m.Frame())
+	for i := 0; i < 10; i++ {
+		m.RunFrame()
+	}
+	fmt.Println(m.Frame(), string(m.Framebuffer().Pixels) == string(before.Pixels))
+	// Output:
+	// 1
+	// 11 true
+}
+
+func ExampleFramebuffer_Image() {
+	m, _ := chip8.New()
+	m.LoadROM([]byte{0x00, 0xE0, 0x12, 0x00})
+	m.RunFrame()
+
+	img := m.Framebuffer().Image(color.Palette{color.Black, color.White})
+	fmt.Println(img.Bounds())
+	// Output: (0,0)-(64,32)
+}
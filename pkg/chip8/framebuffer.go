@@ -0,0 +1,47 @@
+package chip8
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/philw07/pich8-go/internal/palette"
+)
+
+// Framebuffer is a copy of the displayed pixels in the active resolution,
+// which is 64x32 by default, 128x64 in the SUPER-CHIP high resolution and 64x64 in the HI-RES mode
+type Framebuffer struct {
+	Width  int
+	Height int
+	// Pixels holds one value per pixel row by row. Bit 0 is set by the first plane and bit 1 by the second XO-CHIP plane,
+	// so ROMs using only the first plane have the values 0 and 1.
+	Pixels []byte
+}
+
+// At returns the value of the pixel at the given position
+func (fb *Framebuffer) At(x, y int) byte {
+	return fb.Pixels[y*fb.Width+x]
+}
+
+// Image returns the framebuffer as an image with the given colors, which are indexed by the pixel values.
+// The palette needs four colors for XO-CHIP ROMs and at least two otherwise.
+func (fb *Framebuffer) Image(colors color.Palette) *image.Paletted {
+	img := image.NewPaletted(image.Rect(0, 0, fb.Width, fb.Height), colors)
+	copy(img.Pix, fb.Pixels)
+	return img
+}
+
+// Framebuffer returns a copy of the displayed pixels
+func (m *Machine) Framebuffer() *Framebuffer {
+	vmem := m.cpu.Vmem()
+
+	// Low resolution pixels are stored as 2x2 squares
+	size := vmem.RenderWidth() / vmem.Width()
+	fb := Framebuffer{Width: vmem.Width(), Height: vmem.Height()}
+	fb.Pixels = make([]byte, fb.Width*fb.Height)
+	for y := 0; y < fb.Height; y++ {
+		for x := 0; x < fb.Width; x++ {
+			fb.Pixels[y*fb.Width+x] = byte(palette.Index(&vmem, vmem.ToIndex(x*size, y*size)))
+		}
+	}
+	return &fb
+}
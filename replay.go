@@ -0,0 +1,35 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/philw07/pich8-go/internal/movie"
+)
+
+// runReplay replays a movie without window and verifies the final framebuffer
+func runReplay(args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: pich8-go replay movie rom.ch8")
+	}
+
+	m, err := movie.Load(args[0])
+	if err != nil {
+		return err
+	}
+	rom, err := ioutil.ReadFile(args[1])
+	if err != nil {
+		return err
+	}
+
+	hash, err := movie.Replay(m, rom)
+	if err != nil {
+		return err
+	}
+	if hash != m.FinalHash {
+		return fmt.Errorf("framebuffer mismatch after %v frames, expected %v but got %v", m.EndFrame, m.FinalHash, hash)
+	}
+	fmt.Printf("Framebuffer matches after %v frames\n", m.EndFrame)
+	return nil
+}